@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+const usage = `usage: gokit-storage [flags] <command> [args]
+
+commands:
+  ls [prefix]        list objects
+  cat <key>          write an object to stdout
+  put <key> [file]   save a file, or stdin when file is omitted or "-"
+  rm <key>           delete an object
+  cp <src> <dst>     copy an object
+  stat <key>         show an object
+  ping               check the storage is available
+
+environment:
+  TYPE, DISK_ROOT_DIR, S3_BUCKET_NAME, S3_REGION, prefixed by -env-prefix
+
+flags:
+`
+
+type command struct {
+	storage storage.Storage
+	json    bool
+
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+type objectOutput struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	ETag         string    `json:"etag,omitempty"`
+	ContentType  string    `json:"contentType,omitempty"`
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("gokit-storage", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	serviceName := fs.String("service", "", "service name, used as the key prefix of s3")
+	envPrefix := fs.String("env-prefix", "", "prefix of the environment variables, e.g. APP for APP_TYPE")
+	jsonOutput := fs.Bool("json", false, "output in JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("command is required")
+	}
+
+	conf, err := storage.LoadConfigFromEnv(*envPrefix)
+	if err != nil {
+		return err
+	}
+
+	s, err := storage.NewStorage(*serviceName, conf)
+	if err != nil {
+		return err
+	}
+
+	c := &command{
+		storage: s,
+		json:    *jsonOutput,
+		stdin:   stdin,
+		stdout:  stdout,
+	}
+
+	name, cmdArgs := fs.Arg(0), fs.Args()[1:]
+
+	switch name {
+	case "ls":
+		return c.ls(ctx, cmdArgs)
+	case "cat":
+		return c.cat(ctx, cmdArgs)
+	case "put":
+		return c.put(ctx, cmdArgs)
+	case "rm":
+		return c.rm(ctx, cmdArgs)
+	case "cp":
+		return c.cp(ctx, cmdArgs)
+	case "stat":
+		return c.stat(ctx, cmdArgs)
+	case "ping":
+		return c.ping(ctx, cmdArgs)
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown command: %s", name)
+	}
+}
+
+func (c *command) ls(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: ls [prefix]")
+	}
+
+	prefix := ""
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+
+	l, ok := c.storage.(storage.Lister)
+	if !ok {
+		return fmt.Errorf("%T does not support listing", c.storage)
+	}
+
+	infos, err := l.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		outputs := make([]objectOutput, 0, len(infos))
+		for _, info := range infos {
+			outputs = append(outputs, newObjectOutput(info))
+		}
+
+		return c.writeJSON(outputs)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	for _, info := range infos {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", info.Size, info.LastModified.Format(time.RFC3339), info.Key)
+	}
+
+	return w.Flush()
+}
+
+func (c *command) cat(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: cat <key>")
+	}
+
+	data, err := c.get(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = c.stdout.Write(data)
+
+	return err
+}
+
+func (c *command) put(ctx context.Context, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return errors.New("usage: put <key> [file]")
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if len(args) == 1 || args[1] == "-" {
+		data, err = io.ReadAll(c.stdin)
+	} else {
+		data, err = os.ReadFile(args[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	savedPath, err := c.storage.Save(ctx, args[0], data)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.writeJSON(map[string]interface{}{"key": args[0], "path": savedPath, "size": len(data)})
+	}
+
+	fmt.Fprintln(c.stdout, savedPath)
+
+	return nil
+}
+
+func (c *command) rm(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: rm <key>")
+	}
+
+	if err := c.storage.Delete(ctx, args[0]); err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.writeJSON(map[string]interface{}{"key": args[0], "deleted": true})
+	}
+
+	return nil
+}
+
+func (c *command) cp(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: cp <src> <dst>")
+	}
+
+	data, err := c.get(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	savedPath, err := c.storage.Save(ctx, args[1], data)
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.writeJSON(map[string]interface{}{"src": args[0], "dst": args[1], "path": savedPath, "size": len(data)})
+	}
+
+	fmt.Fprintln(c.stdout, savedPath)
+
+	return nil
+}
+
+func (c *command) stat(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: stat <key>")
+	}
+
+	st, ok := c.storage.(storage.Stater)
+	if !ok {
+		return fmt.Errorf("%T does not support stat", c.storage)
+	}
+
+	info, err := st.Stat(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	if info == nil {
+		return fmt.Errorf("%s does not exist", args[0])
+	}
+
+	if c.json {
+		return c.writeJSON(newObjectOutput(*info))
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "key:\t%s\n", info.Key)
+	fmt.Fprintf(w, "size:\t%d\n", info.Size)
+	fmt.Fprintf(w, "last modified:\t%s\n", info.LastModified.Format(time.RFC3339))
+	if info.ETag != "" {
+		fmt.Fprintf(w, "etag:\t%s\n", info.ETag)
+	}
+	if info.ContentType != "" {
+		fmt.Fprintf(w, "content type:\t%s\n", info.ContentType)
+	}
+
+	return w.Flush()
+}
+
+func (c *command) ping(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: ping")
+	}
+
+	if err := c.storage.Ping(ctx); err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.writeJSON(map[string]interface{}{"ok": true})
+	}
+
+	fmt.Fprintln(c.stdout, "ok")
+
+	return nil
+}
+
+func (c *command) get(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil {
+		return nil, fmt.Errorf("%s does not exist", key)
+	}
+
+	return data, nil
+}
+
+func (c *command) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+func newObjectOutput(info object.Info) objectOutput {
+	return objectOutput{
+		Key:          info.Key,
+		Size:         info.Size,
+		LastModified: info.LastModified,
+		ETag:         info.ETag,
+		ContentType:  info.ContentType,
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	t.Setenv("TYPE", "disk")
+	t.Setenv("DISK_ROOT_DIR", t.TempDir())
+
+	ctx := context.Background()
+
+	exec := func(t *testing.T, stdin string, args ...string) string {
+		t.Helper()
+
+		var stdout, stderr bytes.Buffer
+		if err := run(ctx, args, strings.NewReader(stdin), &stdout, &stderr); err != nil {
+			t.Fatalf("%v failed. %v %s", args, err, stderr.String())
+		}
+
+		return stdout.String()
+	}
+
+	exec(t, "hello", "put", "a/test.txt")
+	exec(t, "", "cp", "a/test.txt", "b/test.txt")
+
+	if out := exec(t, "", "cat", "b/test.txt"); out != "hello" {
+		t.Fatalf("unexpected contents. %s", out)
+	}
+
+	var objects []objectOutput
+	if err := json.Unmarshal([]byte(exec(t, "", "-json", "ls", "a/")), &objects); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 1 || objects[0].Key != "a/test.txt" || objects[0].Size != 5 {
+		t.Fatalf("unexpected objects. %+v", objects)
+	}
+
+	exec(t, "", "rm", "a/test.txt")
+
+	var stdout, stderr bytes.Buffer
+	if err := run(ctx, []string{"stat", "a/test.txt"}, nil, &stdout, &stderr); err == nil {
+		t.Fatal("stat of a deleted object should fail")
+	}
+
+	if out := exec(t, "", "ping"); out != "ok\n" {
+		t.Fatalf("unexpected output. %s", out)
+	}
+
+	if err := run(ctx, []string{"unknown"}, nil, &stdout, &stderr); err == nil {
+		t.Fatal("unknown command should fail")
+	}
+}
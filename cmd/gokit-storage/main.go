@@ -0,0 +1,22 @@
+// Command gokit-storage inspects and modifies storages built from storage.Config environment variables.
+//
+//	TYPE=disk DISK_ROOT_DIR=/var/data gokit-storage ls uploads/
+//	TYPE=s3 S3_BUCKET_NAME=bucket gokit-storage -service myservice -json stat uploads/a.png
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "gokit-storage: %s\n", err)
+		os.Exit(1)
+	}
+}
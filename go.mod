@@ -4,17 +4,28 @@ go 1.19
 
 require (
 	github.com/aws/aws-sdk-go v1.44.115
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/go-logr/logr v1.2.2
 	github.com/go-logr/zapr v1.2.2
 	github.com/google/go-cmp v0.5.6
 	github.com/hashicorp/go-retryablehttp v0.7.1
+	github.com/pkg/sftp v1.13.5
+	github.com/vmihailenco/msgpack/v5 v5.3.5
 	go.uber.org/zap v1.19.0
+	golang.org/x/crypto v0.0.0-20211215153901-e495a2d5b3d3
+	golang.org/x/net v0.10.0
+	golang.org/x/time v0.3.0
+	google.golang.org/protobuf v1.28.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/hashicorp/go-cleanhttp v0.5.1 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/kr/fs v0.1.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
+	golang.org/x/sys v0.8.0 // indirect
 	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
 )
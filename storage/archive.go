@@ -0,0 +1,371 @@
+package storage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// ArchiveFormat is the format of the archives written by WriteArchive and read by ExtractArchive.
+type ArchiveFormat string
+
+const (
+	ArchiveFormatZip   ArchiveFormat = "zip"
+	ArchiveFormatTar   ArchiveFormat = "tar"
+	ArchiveFormatTarGz ArchiveFormat = "tar.gz"
+)
+
+const (
+	defaultArchiveMaxFiles     = 10000
+	defaultArchiveMaxTotalSize = 1 << 30
+)
+
+var (
+	// ErrArchiveLimitExceeded is returned by ExtractArchive when the archive exceeds the limits of option.ArchiveOption.
+	ErrArchiveLimitExceeded = errors.New("archive limit exceeded")
+	// ErrUnsafeArchivePath is returned by ExtractArchive when an entry would be extracted outside of the prefix.
+	ErrUnsafeArchivePath = errors.New("unsafe archive path")
+)
+
+// ContentType returns the content type of the format.
+func (f ArchiveFormat) ContentType() string {
+	switch f {
+	case ArchiveFormatZip:
+		return "application/zip"
+	case ArchiveFormatTar:
+		return "application/x-tar"
+	case ArchiveFormatTarGz:
+		return "application/gzip"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// WriteArchive writes all objects of s under prefix into w as an archive, naming the entries by the keys relative to prefix.
+// s must implement Lister. The objects are read one by one, so the archive is streamed to w.
+func WriteArchive(ctx context.Context, w io.Writer, s Storage, prefix string, format ArchiveFormat) (*TransferResult, error) {
+	infos, err := list(ctx, s, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var aw archiveWriter
+	switch format {
+	case ArchiveFormatZip:
+		aw = &zipArchiveWriter{w: zip.NewWriter(w)}
+	case ArchiveFormatTar:
+		aw = &tarArchiveWriter{w: tar.NewWriter(w)}
+	case ArchiveFormatTarGz:
+		gw := gzip.NewWriter(w)
+		aw = &tarArchiveWriter{w: tar.NewWriter(gw), closer: gw}
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+
+	result := &TransferResult{}
+	for _, info := range infos {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		name := strings.TrimPrefix(strings.TrimPrefix(info.Key, prefix), "/")
+		if name == "" {
+			name = path.Base(info.Key)
+		}
+
+		data, err := s.Get(ctx, info.Key)
+		if err != nil {
+			return result, err
+		}
+
+		// the object was deleted after listing
+		if data == nil {
+			result.Skipped = append(result.Skipped, info.Key)
+			continue
+		}
+
+		if err := aw.write(name, info.LastModified, data); err != nil {
+			return result, fmt.Errorf("failed to write %s to the archive. %w", info.Key, err)
+		}
+
+		result.Transferred = append(result.Transferred, info.Key)
+		result.Bytes += int64(len(data))
+	}
+
+	if err := aw.close(); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// SaveArchive saves the archive of all objects of s under prefix to filePath of dst.
+// The archive is built in memory since Storage saves whole objects.
+func SaveArchive(ctx context.Context, s Storage, prefix string, dst Storage, filePath string, format ArchiveFormat) (string, *TransferResult, error) {
+	var buf bytes.Buffer
+
+	result, err := WriteArchive(ctx, &buf, s, prefix, format)
+	if err != nil {
+		return "", result, err
+	}
+
+	savedPath, err := dst.Save(ctx, filePath, buf.Bytes(), option.SaveOptionWithContentType(format.ContentType()))
+	if err != nil {
+		return "", result, err
+	}
+
+	return savedPath, result, nil
+}
+
+// ExtractArchive saves the regular files of the archive read from r to s under prefix.
+// Entries whose path would escape prefix fail with ErrUnsafeArchivePath, and archives exceeding the limits,
+// which default to 10000 files and 1 GiB in total, fail with ErrArchiveLimitExceeded. A limit <= 0 disables it.
+// Directories, links and other special entries are skipped.
+// Files saved before an error are left in s.
+func ExtractArchive(ctx context.Context, s Storage, prefix string, r io.Reader, format ArchiveFormat, opts ...option.ArchiveOptionFunc) (*TransferResult, error) {
+	archiveOpt := option.ArchiveOption{
+		MaxFiles:     defaultArchiveMaxFiles,
+		MaxTotalSize: defaultArchiveMaxTotalSize,
+	}
+	for _, opt := range opts {
+		opt(&archiveOpt)
+	}
+
+	var next func() (*archiveEntry, error)
+	switch format {
+	case ArchiveFormatZip:
+		// the central directory is at the end of zip files, so the whole archive is read first
+		limit := int64(-1)
+		if archiveOpt.MaxTotalSize > 0 {
+			limit = archiveOpt.MaxTotalSize
+		}
+
+		data, err := readLimited(r, limit)
+		if err != nil {
+			return nil, fmt.Errorf("archive: %w", err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+
+		next = zipEntries(zr)
+	case ArchiveFormatTar:
+		next = tarEntries(tar.NewReader(r))
+	case ArchiveFormatTarGz:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+
+		next = tarEntries(tar.NewReader(gr))
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+
+	result := &TransferResult{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		entry, err := next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+
+		if !entry.regular {
+			result.Skipped = append(result.Skipped, entry.name)
+			continue
+		}
+
+		name, err := safeArchivePath(entry.name)
+		if err != nil {
+			return result, err
+		}
+
+		if archiveOpt.MaxFiles > 0 && len(result.Transferred) >= archiveOpt.MaxFiles {
+			return result, fmt.Errorf("archive has more than %d files: %w", archiveOpt.MaxFiles, ErrArchiveLimitExceeded)
+		}
+
+		limit := int64(-1)
+		if archiveOpt.MaxTotalSize > 0 {
+			limit = archiveOpt.MaxTotalSize - result.Bytes
+		}
+		if archiveOpt.MaxFileSize > 0 && (limit < 0 || archiveOpt.MaxFileSize < limit) {
+			limit = archiveOpt.MaxFileSize
+		}
+
+		data, err := readArchiveEntry(entry, limit)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", entry.name, err)
+		}
+
+		key := path.Join(prefix, name)
+		if _, err := s.Save(ctx, key, data); err != nil {
+			return result, err
+		}
+
+		result.Transferred = append(result.Transferred, key)
+		result.Bytes += int64(len(data))
+	}
+}
+
+// safeArchivePath returns the cleaned entry name, or ErrUnsafeArchivePath when it is absolute or escapes the root.
+func safeArchivePath(name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+
+	if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%s: %w", name, ErrUnsafeArchivePath)
+	}
+
+	return cleaned, nil
+}
+
+func readArchiveEntry(entry *archiveEntry, limit int64) ([]byte, error) {
+	rc, err := entry.open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return readLimited(rc, limit)
+}
+
+// readLimited reads r failing with ErrArchiveLimitExceeded when it is larger than limit bytes,
+// regardless of the size declared in the archive. A negative limit means no limit.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit < 0 {
+		return io.ReadAll(r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("larger than %d bytes: %w", limit, ErrArchiveLimitExceeded)
+	}
+
+	return data, nil
+}
+
+type archiveEntry struct {
+	name    string
+	regular bool
+	open    func() (io.ReadCloser, error)
+}
+
+func zipEntries(zr *zip.Reader) func() (*archiveEntry, error) {
+	i := 0
+
+	return func() (*archiveEntry, error) {
+		if i >= len(zr.File) {
+			return nil, io.EOF
+		}
+
+		f := zr.File[i]
+		i++
+
+		return &archiveEntry{
+			name:    f.Name,
+			regular: f.Mode().IsRegular(),
+			open:    f.Open,
+		}, nil
+	}
+}
+
+func tarEntries(tr *tar.Reader) func() (*archiveEntry, error) {
+	return func() (*archiveEntry, error) {
+		h, err := tr.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		return &archiveEntry{
+			name:    h.Name,
+			regular: h.Typeflag == tar.TypeReg,
+			open: func() (io.ReadCloser, error) {
+				return io.NopCloser(tr), nil
+			},
+		}, nil
+	}
+}
+
+type archiveWriter interface {
+	write(name string, modTime time.Time, data []byte) error
+	close() error
+}
+
+type zipArchiveWriter struct {
+	w *zip.Writer
+}
+
+func (z *zipArchiveWriter) write(name string, modTime time.Time, data []byte) error {
+	w, err := z.w.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+
+	return err
+}
+
+func (z *zipArchiveWriter) close() error {
+	return z.w.Close()
+}
+
+type tarArchiveWriter struct {
+	w      *tar.Writer
+	closer io.Closer
+}
+
+func (t *tarArchiveWriter) write(name string, modTime time.Time, data []byte) error {
+	err := t.w.WriteHeader(&tar.Header{
+		Name:     name,
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = t.w.Write(data)
+
+	return err
+}
+
+func (t *tarArchiveWriter) close() error {
+	if err := t.w.Close(); err != nil {
+		return err
+	}
+
+	if t.closer != nil {
+		return t.closer.Close()
+	}
+
+	return nil
+}
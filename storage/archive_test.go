@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/option"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	for _, format := range []ArchiveFormat{ArchiveFormatZip, ArchiveFormatTar, ArchiveFormatTarGz} {
+		format := format
+
+		t.Run(string(format), func(t *testing.T) {
+			ctx := context.Background()
+			src := newTestDisk(t, map[string]string{
+				"reports/a.csv":     "a",
+				"reports/sub/b.csv": "bb",
+				"other/c.csv":       "c",
+			})
+
+			dst := provider.NewMemory()
+
+			_, result, err := SaveArchive(ctx, src, "reports", dst, "bundle."+string(format), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d := cmp.Diff([]string{"reports/a.csv", "reports/sub/b.csv"}, result.Transferred); d != "" {
+				t.Errorf("archived keys were a mismatch. %s", d)
+			}
+
+			info, err := dst.Stat(ctx, "bundle."+string(format))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.ContentType != format.ContentType() {
+				t.Errorf("content type was a mismatch. %s", info.ContentType)
+			}
+
+			data, err := dst.Get(ctx, "bundle."+string(format))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err = ExtractArchive(ctx, dst, "extracted", bytes.NewReader(data), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d := cmp.Diff([]string{"extracted/a.csv", "extracted/sub/b.csv"}, result.Transferred); d != "" {
+				t.Errorf("extracted keys were a mismatch. %s", d)
+			}
+
+			for key, want := range map[string]string{"extracted/a.csv": "a", "extracted/sub/b.csv": "bb"} {
+				got, err := dst.Get(ctx, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if string(got) != want {
+					t.Errorf("%s was a mismatch. expected: %s, actual: %s", key, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractArchiveRejectsUnsafePaths(t *testing.T) {
+	testCases := []struct {
+		name    string
+		format  ArchiveFormat
+		archive []byte
+	}{
+		{
+			name:    "zip with parent directory",
+			format:  ArchiveFormatZip,
+			archive: zipArchive(t, map[string]string{"../../evil.sh": "evil"}),
+		},
+		{
+			name:    "tar with parent directory",
+			format:  ArchiveFormatTar,
+			archive: tarArchive(t, []*tar.Header{{Name: "a/../../evil.sh", Typeflag: tar.TypeReg}}),
+		},
+		{
+			name:    "tar with absolute path",
+			format:  ArchiveFormatTar,
+			archive: tarArchive(t, []*tar.Header{{Name: "/etc/passwd", Typeflag: tar.TypeReg}}),
+		},
+		{
+			name:    "zip with backslashes",
+			format:  ArchiveFormatZip,
+			archive: zipArchive(t, map[string]string{`..\evil.sh`: "evil"}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			m := provider.NewMemory()
+
+			_, err := ExtractArchive(context.Background(), m, "uploads", bytes.NewReader(tc.archive), tc.format)
+			if !errors.Is(err, ErrUnsafeArchivePath) {
+				t.Fatalf("unsafe path should be rejected. %v", err)
+			}
+
+			infos, err := m.List(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(infos) != 0 {
+				t.Fatalf("nothing should be extracted. %+v", infos)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveLimits(t *testing.T) {
+	archive := zipArchive(t, map[string]string{"a.txt": "aaaa", "b.txt": "bbbb"})
+
+	testCases := []struct {
+		name    string
+		opts    []option.ArchiveOptionFunc
+		wantErr bool
+	}{
+		{
+			name: "within limits",
+			opts: []option.ArchiveOptionFunc{option.ArchiveOptionWithMaxFiles(2), option.ArchiveOptionWithMaxTotalSize(1 << 10)},
+		},
+		{
+			name:    "too many files",
+			opts:    []option.ArchiveOptionFunc{option.ArchiveOptionWithMaxFiles(1)},
+			wantErr: true,
+		},
+		{
+			name:    "too large file",
+			opts:    []option.ArchiveOptionFunc{option.ArchiveOptionWithMaxFileSize(3)},
+			wantErr: true,
+		},
+		{
+			name:    "too large in total",
+			opts:    []option.ArchiveOptionFunc{option.ArchiveOptionWithMaxTotalSize(6)},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ExtractArchive(context.Background(), provider.NewMemory(), "", bytes.NewReader(archive), ArchiveFormatZip, tc.opts...)
+			if tc.wantErr != errors.Is(err, ErrArchiveLimitExceeded) {
+				t.Fatalf("err: %v", err)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveSkipsLinks(t *testing.T) {
+	archive := tarArchive(t, []*tar.Header{
+		{Name: "dir/", Typeflag: tar.TypeDir},
+		{Name: "dir/link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		{Name: "dir/file.txt", Typeflag: tar.TypeReg},
+	})
+
+	result, err := ExtractArchive(context.Background(), provider.NewMemory(), "", bytes.NewReader(archive), ArchiveFormatTar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]string{"dir/file.txt"}, result.Transferred); d != "" {
+		t.Errorf("extracted keys were a mismatch. %s", d)
+	}
+
+	if d := cmp.Diff([]string{"dir/", "dir/link"}, result.Skipped); d != "" {
+		t.Errorf("skipped entries were a mismatch. %s", d)
+	}
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// tarArchive writes the headers with the content "x" for regular files.
+func tarArchive(t *testing.T, headers []*tar.Header) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, h := range headers {
+		if h.Typeflag == tar.TypeReg {
+			h.Size = 1
+		}
+
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+
+		if h.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte("x")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
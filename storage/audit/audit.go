@@ -0,0 +1,259 @@
+// Package audit records the mutations of a storage.
+//
+// Auditor wraps a storage.Storage and emits an Event for every Save and Delete to a Sink.
+// Events are written by a background goroutine, so a slow or failing sink never blocks or fails the operations;
+// events which do not fit in the buffer or fail to be written are dropped and counted instead.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+const defaultBufferSize = 1024
+
+type Result string
+
+const (
+	ResultSuccess Result = "success"
+	ResultFailure Result = "failure"
+)
+
+// Event describes a mutation of an object.
+type Event struct {
+	Time      time.Time         `json:"time"`
+	Operation storage.Operation `json:"operation"`
+	Key       string            `json:"key"`
+	// Size and Checksum, the hex encoded SHA-256 of the data, are only set for Save.
+	Size     int64  `json:"size,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+	// Actor is the actor set to the context of the operation by WithActor.
+	Actor   string        `json:"actor,omitempty"`
+	Result  Result        `json:"result"`
+	Error   string        `json:"error,omitempty"`
+	Latency time.Duration `json:"latency"`
+}
+
+// Sink writes audit events.
+// ctx carries the logger of the context of the operation, but not its deadline nor cancellation.
+type Sink interface {
+	Write(ctx context.Context, event Event) error
+}
+
+type actorKey struct{}
+
+// WithActor returns a context recording actor as the one performing the operations.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or "" when it is not set.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+
+	return actor
+}
+
+type entry struct {
+	ctx   context.Context
+	event Event
+}
+
+// Auditor wraps a storage.Storage and emits audit events of Save and Delete to a Sink.
+type Auditor struct {
+	storage storage.Storage
+	sink    Sink
+
+	bufferSize int
+	now        func() time.Time
+
+	mu      sync.RWMutex
+	closed  bool
+	entries chan entry
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+type AuditorOptionFunc func(a *Auditor)
+
+// AuditorOptionWithBufferSize sets how many events can wait for the sink. It defaults to 1024.
+func AuditorOptionWithBufferSize(n int) AuditorOptionFunc {
+	return func(a *Auditor) {
+		a.bufferSize = n
+	}
+}
+
+// AuditorOptionWithClock sets the function returning the current time, which stamps events and measures latency.
+// It defaults to time.Now.
+func AuditorOptionWithClock(now func() time.Time) AuditorOptionFunc {
+	return func(a *Auditor) {
+		a.now = now
+	}
+}
+
+// New returns an Auditor writing events to sink until it is closed.
+func New(s storage.Storage, sink Sink, opts ...AuditorOptionFunc) *Auditor {
+	a := &Auditor{
+		storage:    s,
+		sink:       sink,
+		bufferSize: defaultBufferSize,
+		now:        time.Now,
+		done:       make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	a.entries = make(chan entry, a.bufferSize)
+
+	go a.run()
+
+	return a
+}
+
+// Dropped returns the number of events lost because the buffer was full, the Auditor was closed or the sink failed.
+func (a *Auditor) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// Close stops emitting events and waits until the buffered events are written or ctx is done.
+// The sink is closed as well when it implements io.Closer.
+func (a *Auditor) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+
+		return nil
+	}
+	a.closed = true
+	close(a.entries)
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if c, ok := a.sink.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+func (a *Auditor) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	start := a.now()
+
+	savedPath, err := a.storage.Save(ctx, filePath, data, opts...)
+
+	checksum := sha256.Sum256(data)
+	a.emit(ctx, start, Event{
+		Operation: storage.OperationSave,
+		Key:       filePath,
+		Size:      int64(len(data)),
+		Checksum:  hex.EncodeToString(checksum[:]),
+	}, err)
+
+	return savedPath, err
+}
+
+func (a *Auditor) Get(ctx context.Context, filePath string) ([]byte, error) {
+	return a.storage.Get(ctx, filePath)
+}
+
+func (a *Auditor) Delete(ctx context.Context, filePath string) error {
+	start := a.now()
+
+	err := a.storage.Delete(ctx, filePath)
+
+	a.emit(ctx, start, Event{
+		Operation: storage.OperationDelete,
+		Key:       filePath,
+	}, err)
+
+	return err
+}
+
+func (a *Auditor) Ping(ctx context.Context) error {
+	return a.storage.Ping(ctx)
+}
+
+func (a *Auditor) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	rg, ok := a.storage.(storage.RangeGetter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support range reads: %w", a.storage, storage.ErrNotSupported)
+	}
+
+	return rg.GetRange(ctx, filePath, offset, length)
+}
+
+func (a *Auditor) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	st, ok := a.storage.(storage.Stater)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support stat: %w", a.storage, storage.ErrNotSupported)
+	}
+
+	return st.Stat(ctx, filePath)
+}
+
+func (a *Auditor) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	l, ok := a.storage.(storage.Lister)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support listing: %w", a.storage, storage.ErrNotSupported)
+	}
+
+	return l.List(ctx, prefix)
+}
+
+// emit completes the event and queues it without blocking.
+// Events which do not fit in the buffer are only counted, since logging each of them would slow down the caller
+// and flood the log exactly when the sink is overloaded.
+func (a *Auditor) emit(ctx context.Context, start time.Time, event Event, err error) {
+	event.Time = start
+	event.Actor = ActorFromContext(ctx)
+	event.Latency = a.now().Sub(start)
+	event.Result = ResultSuccess
+	if err != nil {
+		event.Result = ResultFailure
+		event.Error = err.Error()
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		a.dropped.Add(1)
+
+		return
+	}
+
+	select {
+	case a.entries <- entry{ctx: log.WithContext(context.Background(), log.FromContext(ctx)), event: event}:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+func (a *Auditor) run() {
+	defer close(a.done)
+
+	for e := range a.entries {
+		if err := a.sink.Write(e.ctx, e.event); err != nil {
+			a.dropped.Add(1)
+			log.FromContext(e.ctx).Error(err, "failed to write the audit event", "operation", e.event.Operation, "key", e.event.Key)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+type recordSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordSink) Write(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, event)
+
+	return nil
+}
+
+type blockingSink struct {
+	release chan struct{}
+}
+
+func (s *blockingSink) Write(ctx context.Context, event Event) error {
+	<-s.release
+
+	return errors.New("failed")
+}
+
+func TestAuditor(t *testing.T) {
+	current := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sink := &recordSink{}
+	a := New(provider.NewMemory(), sink, AuditorOptionWithClock(func() time.Time { return current }))
+
+	ctx := WithActor(context.Background(), "alice")
+
+	if _, err := a.Save(ctx, "test.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Get(ctx, "test.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Delete(context.Background(), "test.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Event{
+		{
+			Time:      current,
+			Operation: storage.OperationSave,
+			Key:       "test.txt",
+			Size:      4,
+			Checksum:  "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+			Actor:     "alice",
+			Result:    ResultSuccess,
+		},
+		{
+			Time:      current,
+			Operation: storage.OperationDelete,
+			Key:       "test.txt",
+			Result:    ResultSuccess,
+		},
+	}
+	if d := cmp.Diff(want, sink.events); d != "" {
+		t.Errorf("events were a mismatch. %s", d)
+	}
+
+	if _, err := a.Save(ctx, "closed.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+	if a.Dropped() != 1 {
+		t.Errorf("events after Close should be dropped. %d", a.Dropped())
+	}
+}
+
+func TestAuditorDoesNotBlock(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	a := New(provider.NewMemory(), sink, AuditorOptionWithBufferSize(1))
+
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 10; i++ {
+			if _, err := a.Save(ctx, "test.txt", []byte("test")); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Save was blocked by the sink")
+	}
+
+	close(sink.release)
+
+	if err := a.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// every event is either dropped by the full buffer or failed to be written
+	if a.Dropped() != 10 {
+		t.Errorf("unexpected dropped events. %d", a.Dropped())
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "audit.log")
+
+	for i := 0; i < 2; i++ {
+		sink, err := NewFileSink(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a := New(provider.NewMemory(), sink)
+		if err := a.Delete(context.Background(), "test.txt"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := a.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+
+		if event.Operation != storage.OperationDelete || event.Key != "test.txt" {
+			t.Errorf("unexpected event. %+v", event)
+		}
+
+		lines++
+	}
+
+	if lines != 2 {
+		t.Errorf("events should be appended. %d", lines)
+	}
+}
+
+func TestStorageSink(t *testing.T) {
+	ctx := context.Background()
+
+	auditStorage := provider.NewMemory()
+	a := New(provider.NewMemory(), NewStorageSink(auditStorage, "audit"))
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Save(ctx, "test.txt", []byte("test")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := a.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := auditStorage.List(ctx, "audit/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 3 {
+		t.Fatalf("each event should be saved as an object. %+v", infos)
+	}
+
+	data, err := auditStorage.Get(ctx, infos[0].Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatal(err)
+	}
+
+	if event.Operation != storage.OperationSave || event.Size != 4 {
+		t.Errorf("unexpected event. %+v", event)
+	}
+}
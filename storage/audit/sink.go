@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// LogSink writes events to the logger from log.FromContext.
+type LogSink struct{}
+
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (s *LogSink) Write(ctx context.Context, event Event) error {
+	log.FromContext(ctx).Info("audit",
+		"time", event.Time,
+		"operation", event.Operation,
+		"key", event.Key,
+		"size", event.Size,
+		"checksum", event.Checksum,
+		"actor", event.Actor,
+		"result", event.Result,
+		"error", event.Error,
+		"latency", event.Latency,
+	)
+
+	return nil
+}
+
+// FileSink appends events to a file as JSON lines.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewFileSink opens filePath for appending, creating it when it does not exist.
+func NewFileSink(filePath string) (*FileSink, error) {
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log. %w", err)
+	}
+
+	return &FileSink{
+		file: f,
+		enc:  json.NewEncoder(f),
+	}, nil
+}
+
+func (s *FileSink) Write(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.enc.Encode(event)
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// StorageSink saves each event as a JSON object under a prefix of a storage, so events are never overwritten.
+// The key is "<prefix>/<yyyy>/<mm>/<dd>/<time>-<random>.json" in UTC, which sorts by time.
+// The storage must not be the Auditor writing to the sink, or one wrapping it, since saving each event would be
+// audited as another event without end.
+type StorageSink struct {
+	storage storage.Storage
+	prefix  string
+}
+
+func NewStorageSink(s storage.Storage, prefix string) *StorageSink {
+	return &StorageSink{
+		storage: s,
+		prefix:  prefix,
+	}
+}
+
+func (s *StorageSink) Write(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode the audit event. %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+
+	t := event.Time.UTC()
+	key := path.Join(s.prefix, t.Format("2006/01/02"), t.Format("150405.000000000")+"-"+hex.EncodeToString(suffix)+".json")
+
+	_, err = s.storage.Save(ctx, key, data, option.SaveOptionWithContentType("application/json"))
+
+	return err
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// Composer is implemented by storages that can concatenate objects into a new object without the caller reading them.
+type Composer interface {
+	Compose(ctx context.Context, filePath string, sources []string, opts ...option.SaveOptionFunc) (string, error)
+}
+
+// Compose saves the concatenation of the sources to filePath.
+// It uses Composer when s implements it, otherwise it reads all sources into memory and saves them.
+func Compose(ctx context.Context, s Storage, filePath string, sources []string, opts ...option.SaveOptionFunc) (string, error) {
+	if c, ok := s.(Composer); ok {
+		return c.Compose(ctx, filePath, sources, opts...)
+	}
+
+	var data []byte
+	for _, src := range sources {
+		b, err := s.Get(ctx, src)
+		if err != nil {
+			return "", err
+		}
+
+		if b == nil {
+			return "", fmt.Errorf("%s does not exist", src)
+		}
+
+		data = append(data, b...)
+	}
+
+	return s.Save(ctx, filePath, data, opts...)
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// plainStorage hides the optional interfaces of the embedded storage.
+type plainStorage struct {
+	Storage
+}
+
+func TestCompose(t *testing.T) {
+	testCases := []struct {
+		name    string
+		wrap    func(Storage) Storage
+		sources []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "composer",
+			wrap:    func(s Storage) Storage { return s },
+			sources: []string{"a", "b"},
+			want:    map[string]string{"a": "foo", "b": "bar", "out": "foobar"},
+		},
+		{
+			name:    "fallback",
+			wrap:    func(s Storage) Storage { return plainStorage{s} },
+			sources: []string{"a", "b"},
+			want:    map[string]string{"a": "foo", "b": "bar", "out": "foobar"},
+		},
+		{
+			name:    "fallback with missing source",
+			wrap:    func(s Storage) Storage { return plainStorage{s} },
+			sources: []string{"a", "missing"},
+			want:    map[string]string{"a": "foo", "b": "bar"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			d := newTestDisk(t, map[string]string{"a": "foo", "b": "bar"})
+
+			_, err := Compose(context.Background(), tc.wrap(d), "out", tc.sources)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, readAll(t, d)); diff != "" {
+				t.Errorf("objects were a mismatch. %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/hatappi/go-kit/storage/option"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+// ErrPreconditionFailed is returned by ConditionalWriter when the object exists or was changed.
+var ErrPreconditionFailed = provider.ErrPreconditionFailed
+
+// ConditionalWriter is implemented by storages that can write an object only when it is in the expected state.
+// ETags are opaque and only comparable to the ones returned by the same storage.
+// The Disk provider relies on flock, so its conditional operations other than SaveIfAbsent fail with ErrNotSupported
+// on platforms without it, such as Windows.
+type ConditionalWriter interface {
+	// GetWithETag returns the data and the ETag of the object. The data is nil when the object does not exist.
+	GetWithETag(ctx context.Context, filePath string) ([]byte, string, error)
+	// SaveIfAbsent saves the object only when it does not exist and returns the ETag of the saved object.
+	SaveIfAbsent(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error)
+	// SaveIfMatch replaces the object only when its ETag equals etag and returns the ETag of the saved object.
+	SaveIfMatch(ctx context.Context, filePath string, data []byte, etag string, opts ...option.SaveOptionFunc) (string, error)
+	// DeleteIfMatch deletes the object only when its ETag equals etag.
+	DeleteIfMatch(ctx context.Context, filePath string, etag string) error
+}
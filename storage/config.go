@@ -1,7 +1,21 @@
 package storage
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
 type Config struct {
-	Type StorageType `envconfig:"TYPE" validate:"oneof=disk s3"`
+	Type StorageType `envconfig:"TYPE" validate:"required"`
 
 	Disk struct {
 		RootDir string `envconfig:"ROOT_DIR"`
@@ -11,4 +25,405 @@ type Config struct {
 		BucketName string `envconfig:"BUCKET_NAME"`
 		Region     string `default:"ap-northeast-1" envconfig:"REGION"`
 	} `envconfig:"S3"`
+
+	SFTP struct {
+		// Addr is the host:port of the server.
+		Addr           string `envconfig:"ADDR"`
+		User           string `envconfig:"USER"`
+		PrivateKeyPath string `envconfig:"PRIVATE_KEY_PATH"`
+		KnownHostsPath string `envconfig:"KNOWN_HOSTS_PATH"`
+		RootDir        string `envconfig:"ROOT_DIR"`
+		MaxConns       int    `default:"4" envconfig:"MAX_CONNS"`
+	} `envconfig:"SFTP"`
+
+	WebDAV struct {
+		URL string `envconfig:"URL"`
+		// Username and Password are used for basic auth. They are ignored when BearerToken is set.
+		Username    string `envconfig:"USERNAME"`
+		Password    string `envconfig:"PASSWORD"`
+		BearerToken string `envconfig:"BEARER_TOKEN"`
+	} `envconfig:"WEBDAV"`
+
+	// Options is the config of providers registered by Register.
+	// It is loaded from environment variables in the form of key1:value1,key2:value2.
+	Options map[string]string `envconfig:"OPTIONS"`
+}
+
+// FieldError describes an invalid field of Config.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// ValidationError aggregates all invalid fields of Config.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Error())
+	}
+
+	return "invalid storage config: " + strings.Join(msgs, ", ")
+}
+
+// LoadConfigFromEnv loads Config from environment variables named by the envconfig tags joined with prefix,
+// e.g. PREFIX_TYPE and PREFIX_DISK_ROOT_DIR, applies defaults and validates it.
+func LoadConfigFromEnv(prefix string) (*Config, error) {
+	var conf Config
+
+	err := loadConfig(&conf, func(names []string) (interface{}, bool) {
+		if prefix != "" {
+			names = append([]string{prefix}, names...)
+		}
+
+		return os.LookupEnv(strings.ToUpper(strings.Join(names, "_")))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+// LoadConfigFromFile loads Config from a JSON or YAML file, applies defaults and validates it.
+// Keys are matched to the envconfig tags ignoring case and underscores, e.g. root_dir or rootDir for ROOT_DIR.
+func LoadConfigFromFile(filePath string) (*Config, error) {
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		err = dec.Decode(&values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &values)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension: %s", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var conf Config
+
+	err = loadConfig(&conf, func(names []string) (interface{}, bool) {
+		return lookupValue(values, names)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+// Validate checks the fields required by the storage type and the validate tags.
+func (c *Config) Validate() error {
+	var errs []*FieldError
+
+	for _, f := range configFields(reflect.ValueOf(c).Elem(), nil, "") {
+		switch tag := f.field.Tag.Get("validate"); {
+		case tag == "required":
+			if f.value.IsZero() {
+				errs = append(errs, &FieldError{Field: f.path, Message: "is required"})
+			}
+		case strings.HasPrefix(tag, "oneof="):
+			choices := strings.Fields(strings.TrimPrefix(tag, "oneof="))
+			if !contains(choices, f.value.String()) {
+				errs = append(errs, &FieldError{
+					Field:   f.path,
+					Message: fmt.Sprintf("must be one of [%s] but got %q", strings.Join(choices, " "), f.value.String()),
+				})
+			}
+		}
+	}
+
+	if _, ok := lookupFactory(c.Type); c.Type != "" && !ok {
+		errs = append(errs, &FieldError{
+			Field:   "Type",
+			Message: fmt.Sprintf("must be one of registered types [%s] but got %q", strings.Join(registeredTypes(), " "), c.Type),
+		})
+	}
+
+	switch c.Type {
+	case StorageTypeDisk:
+		if c.Disk.RootDir == "" {
+			errs = append(errs, &FieldError{Field: "Disk.RootDir", Message: "is required"})
+		}
+	case StorageTypeS3:
+		if c.S3.BucketName == "" {
+			errs = append(errs, &FieldError{Field: "S3.BucketName", Message: "is required"})
+		}
+	case StorageTypeSFTP:
+		for _, f := range []struct{ field, value string }{
+			{field: "SFTP.Addr", value: c.SFTP.Addr},
+			{field: "SFTP.User", value: c.SFTP.User},
+			{field: "SFTP.PrivateKeyPath", value: c.SFTP.PrivateKeyPath},
+			{field: "SFTP.KnownHostsPath", value: c.SFTP.KnownHostsPath},
+		} {
+			if f.value == "" {
+				errs = append(errs, &FieldError{Field: f.field, Message: "is required"})
+			}
+		}
+	case StorageTypeWebDAV:
+		if c.WebDAV.URL == "" {
+			errs = append(errs, &FieldError{Field: "WebDAV.URL", Message: "is required"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// DecodeOptions sets Options to the fields of v, which must be a pointer to a struct.
+// Keys are matched to the envconfig tags or the field names ignoring case and underscores.
+// string, bool, integer and time.Duration fields are supported.
+func (c *Config) DecodeOptions(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("options must be decoded into a pointer to a struct, got %T", v)
+	}
+
+	var errs []*FieldError
+
+	for _, f := range configFields(rv.Elem(), nil, "") {
+		var (
+			value string
+			ok    bool
+		)
+		for k, v := range c.Options {
+			if normalizeKey(k) == normalizeKey(strings.Join(f.names, "_")) {
+				value, ok = v, true
+				break
+			}
+		}
+
+		if !ok {
+			value, ok = f.field.Tag.Lookup("default")
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := setString(f.value, value); err != nil {
+			errs = append(errs, &FieldError{Field: "Options." + f.path, Message: err.Error()})
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+type configField struct {
+	names []string
+	path  string
+	field reflect.StructField
+	value reflect.Value
+}
+
+// configFields returns the leaf fields of v with the envconfig tag names leading to them.
+func configFields(v reflect.Value, names []string, path string) []configField {
+	var fields []configField
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := sf.Tag.Get("envconfig")
+		if name == "" {
+			name = sf.Name
+		}
+
+		fieldNames := append(append([]string{}, names...), name)
+		fieldPath := sf.Name
+		if path != "" {
+			fieldPath = path + "." + sf.Name
+		}
+
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, configFields(v.Field(i), fieldNames, fieldPath)...)
+			continue
+		}
+
+		fields = append(fields, configField{
+			names: fieldNames,
+			path:  fieldPath,
+			field: sf,
+			value: v.Field(i),
+		})
+	}
+
+	return fields
+}
+
+func loadConfig(conf *Config, lookup func(names []string) (interface{}, bool)) error {
+	var errs []*FieldError
+
+	for _, f := range configFields(reflect.ValueOf(conf).Elem(), nil, "") {
+		value, ok := lookup(f.names)
+		if !ok {
+			value, ok = f.field.Tag.Lookup("default")
+		}
+
+		if !ok {
+			continue
+		}
+
+		var err error
+		switch v := value.(type) {
+		case string:
+			err = setString(f.value, v)
+		case map[string]interface{}:
+			err = setMap(f.value, v)
+		default:
+			err = setString(f.value, fmt.Sprint(v))
+		}
+
+		if err != nil {
+			errs = append(errs, &FieldError{Field: f.path, Message: err.Error()})
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// setString sets s to v converting it to the type of v.
+// A map[string]string is parsed from the form of key1:value1,key2:value2.
+func setString(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("is invalid duration %q", s)
+		}
+
+		v.SetInt(int64(d))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("is invalid bool %q", s)
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("is invalid integer %q", s)
+		}
+
+		v.SetInt(i)
+	case reflect.Map:
+		m := map[string]interface{}{}
+		for _, pair := range strings.Split(s, ",") {
+			if pair == "" {
+				continue
+			}
+
+			kv := strings.SplitN(pair, ":", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("is invalid map entry %q, must be key:value", pair)
+			}
+
+			m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+
+		return setMap(v, m)
+	default:
+		return fmt.Errorf("has unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func setMap(v reflect.Value, m map[string]interface{}) error {
+	if v.Type() != reflect.TypeOf(map[string]string{}) {
+		return fmt.Errorf("has unsupported type %s", v.Type())
+	}
+
+	values := make(map[string]string, len(m))
+	for k, val := range m {
+		values[k] = fmt.Sprint(val)
+	}
+
+	v.Set(reflect.ValueOf(values))
+
+	return nil
+}
+
+func lookupValue(values map[string]interface{}, names []string) (interface{}, bool) {
+	for k, v := range values {
+		if normalizeKey(k) != normalizeKey(names[0]) {
+			continue
+		}
+
+		if len(names) == 1 {
+			if v == nil {
+				return nil, false
+			}
+
+			return v, true
+		}
+
+		child, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		return lookupValue(child, names[1:])
+	}
+
+	return nil, false
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
 }
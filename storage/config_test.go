@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("APP_TYPE", "s3")
+	t.Setenv("APP_S3_BUCKET_NAME", "test")
+
+	conf, err := LoadConfigFromEnv("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.Type != StorageTypeS3 || conf.S3.BucketName != "test" {
+		t.Fatalf("unexpected config. %+v", conf)
+	}
+
+	if conf.S3.Region != "ap-northeast-1" {
+		t.Fatalf("default region was not applied. %s", conf.S3.Region)
+	}
+}
+
+func TestLoadConfigFromFile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fileName string
+		content  string
+
+		wantType    StorageType
+		wantRootDir string
+		wantErr     bool
+	}{
+		{
+			name:        "json",
+			fileName:    "config.json",
+			content:     `{"type": "disk", "disk": {"rootDir": "/tmp"}}`,
+			wantType:    StorageTypeDisk,
+			wantRootDir: "/tmp",
+		},
+		{
+			name:        "yaml",
+			fileName:    "config.yaml",
+			content:     "type: disk\ndisk:\n  root_dir: /tmp\n",
+			wantType:    StorageTypeDisk,
+			wantRootDir: "/tmp",
+		},
+		{
+			name:     "invalid config",
+			fileName: "config.yml",
+			content:  "type: disk\n",
+			wantErr:  true,
+		},
+		{
+			name:     "unsupported extension",
+			fileName: "config.toml",
+			content:  `type = "disk"`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tc.fileName)
+			if err := os.WriteFile(filePath, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			conf, err := LoadConfigFromFile(filePath)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err: %v", err)
+			}
+
+			if tc.wantErr {
+				return
+			}
+
+			if conf.Type != tc.wantType || conf.Disk.RootDir != tc.wantRootDir {
+				t.Fatalf("unexpected config. %+v", conf)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config *Config
+
+		wantFields []string
+	}{
+		{
+			name: "valid",
+			config: func() *Config {
+				conf := &Config{Type: StorageTypeDisk}
+				conf.Disk.RootDir = "/"
+				return conf
+			}(),
+			wantFields: nil,
+		},
+		{
+			name:       "disk without root dir",
+			config:     &Config{Type: StorageTypeDisk},
+			wantFields: []string{"Disk.RootDir"},
+		},
+		{
+			name:       "s3 without bucket name",
+			config:     &Config{Type: StorageTypeS3},
+			wantFields: []string{"S3.BucketName"},
+		},
+		{
+			name: "sftp without keys",
+			config: func() *Config {
+				conf := &Config{Type: StorageTypeSFTP}
+				conf.SFTP.Addr = "localhost:22"
+				conf.SFTP.User = "test"
+
+				return conf
+			}(),
+			wantFields: []string{"SFTP.PrivateKeyPath", "SFTP.KnownHostsPath"},
+		},
+		{
+			name:       "webdav without url",
+			config:     &Config{Type: StorageTypeWebDAV},
+			wantFields: []string{"WebDAV.URL"},
+		},
+		{
+			name:       "invalid type",
+			config:     &Config{Type: "test"},
+			wantFields: []string{"Type"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+
+			var fields []string
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				for _, fe := range verr.Errors {
+					fields = append(fields, fe.Field)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error. %v", err)
+			}
+
+			if d := cmp.Diff(tc.wantFields, fields); d != "" {
+				t.Fatalf("unexpected invalid fields. %s", d)
+			}
+		})
+	}
+}
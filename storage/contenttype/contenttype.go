@@ -0,0 +1,49 @@
+package contenttype
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Detector detects content types from file extensions and data.
+type Detector struct {
+	extensions map[string]string
+}
+
+// NewDetector returns a Detector which prefers the content types of overrides keyed by extension, e.g. ".md": "text/markdown".
+func NewDetector(overrides map[string]string) *Detector {
+	extensions := make(map[string]string, len(overrides))
+	for ext, ct := range overrides {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		extensions[strings.ToLower(ext)] = ct
+	}
+
+	return &Detector{
+		extensions: extensions,
+	}
+}
+
+// Detect returns the content type of a file from its extension, or from its data when the extension is unknown.
+func (d *Detector) Detect(name string, data []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+
+	if ct, ok := d.extensions[ext]; ok {
+		return ct
+	}
+
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+
+	return http.DetectContentType(data)
+}
+
+// Detect detects the content type with the default mapping.
+func Detect(name string, data []byte) string {
+	return (&Detector{}).Detect(name, data)
+}
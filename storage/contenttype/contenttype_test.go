@@ -0,0 +1,55 @@
+package contenttype
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		name string
+		file string
+		data []byte
+
+		want string
+	}{
+		{
+			name: "known extension",
+			file: "index.html",
+			data: []byte("test"),
+			want: "text/html; charset=utf-8",
+		},
+		{
+			name: "unknown extension",
+			file: "image",
+			data: []byte("\x89PNG\x0D\x0A\x1A\x0A"),
+			want: "image/png",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Detect(tc.file, tc.data); actual != tc.want {
+				t.Errorf("content type was a mismatch. expected: %s, actual: %s", tc.want, actual)
+			}
+		})
+	}
+}
+
+func TestDetectorDetect(t *testing.T) {
+	d := NewDetector(map[string]string{
+		"md":    "text/markdown",
+		".HTML": "application/xhtml+xml",
+	})
+
+	if actual := d.Detect("README.md", nil); actual != "text/markdown" {
+		t.Errorf("override without dot was not used. %s", actual)
+	}
+
+	if actual := d.Detect("index.html", nil); actual != "application/xhtml+xml" {
+		t.Errorf("override should be case insensitive. %s", actual)
+	}
+
+	if actual := d.Detect("style.css", nil); actual != "text/css; charset=utf-8" {
+		t.Errorf("default mapping was not used. %s", actual)
+	}
+}
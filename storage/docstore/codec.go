@@ -0,0 +1,108 @@
+package docstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes documents.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal decodes data into v, which is a pointer to the document.
+	Unmarshal(data []byte, v interface{}) error
+	// ContentType is set to the saved objects.
+	ContentType() string
+	// Extension is appended to the document IDs to build the object keys, e.g. ".json".
+	Extension() string
+}
+
+// JSON encodes documents by encoding/json.
+var JSON Codec = jsonCodec{}
+
+// MsgPack encodes documents by github.com/vmihailenco/msgpack/v5.
+var MsgPack Codec = msgpackCodec{}
+
+// Protobuf encodes documents that are pointers to protobuf messages, e.g. Store[*pb.User].
+var Protobuf Codec = protobufCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Extension() string {
+	return ".json"
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackCodec) Extension() string {
+	return ".msgpack"
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a protobuf message", v)
+	}
+
+	return proto.Marshal(m)
+}
+
+// Unmarshal allocates the message when v is a pointer to a nil message pointer.
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Ptr {
+		return fmt.Errorf("%T is not a pointer to a protobuf message", v)
+	}
+
+	if rv.Elem().IsNil() {
+		rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+	}
+
+	m, ok := rv.Elem().Interface().(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a pointer to a protobuf message", v)
+	}
+
+	return proto.Unmarshal(data, m)
+}
+
+func (protobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (protobufCodec) Extension() string {
+	return ".pb"
+}
@@ -0,0 +1,229 @@
+// Package docstore stores typed documents in a storage.
+package docstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+const defaultMaxRetries = 10
+
+var (
+	// ErrNotFound is returned when the document does not exist.
+	ErrNotFound = errors.New("document not found")
+	// ErrConflict is returned by Update when the document kept being changed concurrently.
+	ErrConflict = errors.New("document was changed concurrently")
+	// ErrInvalidID is returned when the ID is empty, absolute or refers outside of the prefix.
+	ErrInvalidID = errors.New("invalid document ID")
+	// ErrInvalidPrefix is returned by New when the prefix is empty, absolute or refers outside of the storage.
+	ErrInvalidPrefix = errors.New("invalid prefix")
+)
+
+type config struct {
+	prefix     string
+	codec      Codec
+	maxRetries int
+}
+
+type Option func(*config)
+
+// WithCodec sets the codec of the documents. It defaults to JSON.
+func WithCodec(codec Codec) Option {
+	return func(c *config) {
+		c.codec = codec
+	}
+}
+
+// WithMaxRetries sets how many times Update retries when the document was changed concurrently. It defaults to 10.
+func WithMaxRetries(n int) Option {
+	return func(c *config) {
+		c.maxRetries = n
+	}
+}
+
+// Store stores documents of type T. A document is saved to the object keyed by the prefix, the ID and the codec extension.
+type Store[T any] struct {
+	storage storage.Storage
+	config
+}
+
+// New returns a Store keeping its documents under prefix.
+// The prefix is required so that List only finds the documents of the store, and should not be shared by other stores.
+func New[T any](s storage.Storage, prefix string, opts ...Option) (*Store[T], error) {
+	clean := path.Clean(prefix)
+	if prefix == "" || path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return nil, fmt.Errorf("%q: %w", prefix, ErrInvalidPrefix)
+	}
+
+	c := config{
+		prefix:     clean,
+		codec:      JSON,
+		maxRetries: defaultMaxRetries,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &Store[T]{
+		storage: s,
+		config:  c,
+	}, nil
+}
+
+// Put saves the document with the content type of the codec.
+func (s *Store[T]) Put(ctx context.Context, id string, doc T) error {
+	key, err := s.key(id)
+	if err != nil {
+		return err
+	}
+
+	data, err := s.codec.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s. %w", id, err)
+	}
+
+	_, err = s.storage.Save(ctx, key, data, s.saveOptions()...)
+
+	return err
+}
+
+// Get returns ErrNotFound when the document does not exist.
+func (s *Store[T]) Get(ctx context.Context, id string) (T, error) {
+	var doc T
+
+	key, err := s.key(id)
+	if err != nil {
+		return doc, err
+	}
+
+	data, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return doc, err
+	}
+
+	if data == nil {
+		return doc, fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+
+	return s.decode(id, data)
+}
+
+func (s *Store[T]) Delete(ctx context.Context, id string) error {
+	key, err := s.key(id)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Delete(ctx, key)
+}
+
+// List returns the IDs of the documents starting with idPrefix. The storage has to implement storage.Lister.
+func (s *Store[T]) List(ctx context.Context, idPrefix string) ([]string, error) {
+	l, ok := s.storage.(storage.Lister)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support listing: %w", s.storage, storage.ErrNotSupported)
+	}
+
+	keyPrefix := s.prefix + "/"
+
+	infos, err := l.List(ctx, keyPrefix+idPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if !strings.HasSuffix(info.Key, s.codec.Extension()) {
+			continue
+		}
+
+		ids = append(ids, strings.TrimSuffix(strings.TrimPrefix(info.Key, keyPrefix), s.codec.Extension()))
+	}
+
+	return ids, nil
+}
+
+// Update applies fn to the document and saves the result only when the document was not changed in the meantime,
+// retrying with the latest document otherwise. The storage has to implement storage.ConditionalWriter.
+// It returns ErrNotFound when the document does not exist and ErrConflict when the retries are exhausted.
+func (s *Store[T]) Update(ctx context.Context, id string, fn func(T) (T, error)) (T, error) {
+	var zero T
+
+	cw, ok := s.storage.(storage.ConditionalWriter)
+	if !ok {
+		return zero, fmt.Errorf("%T does not support conditional writes: %w", s.storage, storage.ErrNotSupported)
+	}
+
+	key, err := s.key(id)
+	if err != nil {
+		return zero, err
+	}
+
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		data, etag, err := cw.GetWithETag(ctx, key)
+		if err != nil {
+			return zero, err
+		}
+
+		if data == nil {
+			return zero, fmt.Errorf("%s: %w", id, ErrNotFound)
+		}
+
+		doc, err := s.decode(id, data)
+		if err != nil {
+			return zero, err
+		}
+
+		doc, err = fn(doc)
+		if err != nil {
+			return zero, err
+		}
+
+		data, err = s.codec.Marshal(doc)
+		if err != nil {
+			return zero, fmt.Errorf("failed to encode %s. %w", id, err)
+		}
+
+		_, err = cw.SaveIfMatch(ctx, key, data, etag, s.saveOptions()...)
+		if errors.Is(err, storage.ErrPreconditionFailed) {
+			continue
+		}
+		if err != nil {
+			return zero, err
+		}
+
+		return doc, nil
+	}
+
+	return zero, fmt.Errorf("%s: %w", id, ErrConflict)
+}
+
+func (s *Store[T]) decode(id string, data []byte) (T, error) {
+	var doc T
+
+	if err := s.codec.Unmarshal(data, &doc); err != nil {
+		return doc, fmt.Errorf("failed to decode %s. %w", id, err)
+	}
+
+	return doc, nil
+}
+
+// key returns ErrInvalidID when id is empty, absolute or its cleaned path leaves the prefix.
+func (s *Store[T]) key(id string) (string, error) {
+	clean := path.Clean(id)
+	if id == "" || path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%q: %w", id, ErrInvalidID)
+	}
+
+	return path.Join(s.prefix, clean) + s.codec.Extension(), nil
+}
+
+func (s *Store[T]) saveOptions() []option.SaveOptionFunc {
+	return []option.SaveOptionFunc{option.SaveOptionWithContentType(s.codec.ContentType())}
+}
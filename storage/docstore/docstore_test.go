@@ -0,0 +1,273 @@
+package docstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/option"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+type user struct {
+	Name  string `json:"name" msgpack:"name"`
+	Count int    `json:"count" msgpack:"count"`
+}
+
+func TestStore(t *testing.T) {
+	testCases := []struct {
+		name            string
+		codec           Codec
+		wantKey         string
+		wantContentType string
+	}{
+		{
+			name:            "json",
+			codec:           JSON,
+			wantKey:         "users/alice.json",
+			wantContentType: "application/json",
+		},
+		{
+			name:            "msgpack",
+			codec:           MsgPack,
+			wantKey:         "users/alice.msgpack",
+			wantContentType: "application/msgpack",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := provider.NewMemory()
+			s := newTestStore[user](t, m, "users", WithCodec(tc.codec))
+
+			// objects outside of the prefix are not documents of the store
+			if _, err := m.Save(ctx, "config.json", []byte("{}")); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, id := range []string{"alice", "bob", "carol"} {
+				if err := s.Put(ctx, id, user{Name: id}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			info, err := m.Stat(ctx, tc.wantKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info == nil || info.ContentType != tc.wantContentType {
+				t.Fatalf("unexpected object. %+v", info)
+			}
+
+			got, err := s.Get(ctx, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(user{Name: "alice"}, got); d != "" {
+				t.Errorf("document was a mismatch. %s", d)
+			}
+
+			if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("missing document should not be found. %v", err)
+			}
+
+			ids, err := s.List(ctx, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff([]string{"alice", "bob", "carol"}, ids); d != "" {
+				t.Errorf("ids were a mismatch. %s", d)
+			}
+
+			ids, err = s.List(ctx, "b")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff([]string{"bob"}, ids); d != "" {
+				t.Errorf("ids were a mismatch. %s", d)
+			}
+
+			if err := s.Delete(ctx, "bob"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.Get(ctx, "bob"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("deleted document should not be found. %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreProtobuf(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore[*wrapperspb.StringValue](t, provider.NewMemory(), "greetings", WithCodec(Protobuf))
+
+	if err := s.Put(ctx, "greeting", wrapperspb.String("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Get(ctx, "greeting")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.GetValue() != "hello" {
+		t.Fatalf("unexpected document. %v", got)
+	}
+}
+
+func TestStoreUpdate(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore[user](t, provider.NewDisk(t.TempDir()), "users")
+
+	if err := s.Put(ctx, "alice", user{Name: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := s.Update(ctx, "alice", func(u user) (user, error) {
+				u.Count++
+				return u, nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := s.Get(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Count != workers {
+		t.Fatalf("updates were lost. count: %d", got.Count)
+	}
+
+	if _, err := s.Update(ctx, "missing", func(u user) (user, error) { return u, nil }); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing document should not be updated. %v", err)
+	}
+}
+
+// conflictingStorage changes the document before every conditional write.
+type conflictingStorage struct {
+	*provider.Memory
+	n int
+}
+
+func (c *conflictingStorage) SaveIfMatch(ctx context.Context, filePath string, data []byte, etag string, opts ...option.SaveOptionFunc) (string, error) {
+	c.n++
+	if _, err := c.Memory.Save(ctx, filePath, []byte(`{"name":"`+strconv.Itoa(c.n)+`"}`)); err != nil {
+		return "", err
+	}
+
+	return c.Memory.SaveIfMatch(ctx, filePath, data, etag, opts...)
+}
+
+func TestStoreUpdateConflict(t *testing.T) {
+	ctx := context.Background()
+	cs := &conflictingStorage{Memory: provider.NewMemory()}
+	s := newTestStore[user](t, cs, "users", WithMaxRetries(2))
+
+	if err := s.Put(ctx, "alice", user{Name: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := s.Update(ctx, "alice", func(u user) (user, error) { return u, nil })
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("exhausted retries should conflict. %v", err)
+	}
+
+	if cs.n != 3 {
+		t.Fatalf("unexpected attempts. %d", cs.n)
+	}
+}
+
+func TestStoreUpdateWithoutConditionalWriter(t *testing.T) {
+	s := newTestStore[user](t, storage.ReadOnly(provider.NewMemory()), "users")
+
+	_, err := s.Update(context.Background(), "alice", func(u user) (user, error) { return u, nil })
+	if !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("storage without conditional writes should not be supported. %v", err)
+	}
+}
+
+func TestStoreInvalidID(t *testing.T) {
+	ctx := context.Background()
+
+	m := provider.NewMemory()
+	if _, err := m.Save(ctx, "other/x.json", []byte(`{"name":"other"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestStore[user](t, m, "users")
+
+	for _, id := range []string{"", ".", "..", "../other/x", "a/../../other/x", "/other/x"} {
+		if err := s.Put(ctx, id, user{Name: "overwritten"}); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Put of %q should fail with ErrInvalidID. %v", id, err)
+		}
+
+		if _, err := s.Get(ctx, id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Get of %q should fail with ErrInvalidID. %v", id, err)
+		}
+
+		if _, err := s.Update(ctx, id, func(u user) (user, error) { return u, nil }); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Update of %q should fail with ErrInvalidID. %v", id, err)
+		}
+
+		if err := s.Delete(ctx, id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Delete of %q should fail with ErrInvalidID. %v", id, err)
+		}
+	}
+
+	data, err := m.Get(ctx, "other/x.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"name":"other"}` {
+		t.Errorf("object outside of the prefix was changed. %s", data)
+	}
+
+	if err := s.Put(ctx, "a/../b", user{Name: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "b"); err != nil {
+		t.Errorf("cleaned ID should stay under the prefix. %v", err)
+	}
+}
+
+func TestNewInvalidPrefix(t *testing.T) {
+	for _, prefix := range []string{"", ".", "..", "../users", "/users"} {
+		if _, err := New[user](provider.NewMemory(), prefix); !errors.Is(err, ErrInvalidPrefix) {
+			t.Errorf("prefix %q should fail with ErrInvalidPrefix. %v", prefix, err)
+		}
+	}
+}
+
+func newTestStore[T any](t *testing.T, s storage.Storage, prefix string, opts ...Option) *Store[T] {
+	t.Helper()
+
+	store, err := New[T](s, prefix, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
@@ -0,0 +1,179 @@
+package httpserve
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+// Authorizer decides whether the request may read the object of key.
+// A returned error is sent to the client as 403 Forbidden.
+type Authorizer func(r *http.Request, key string) error
+
+// Handler serves objects of a storage.Storage keyed by the URL path without the leading slash.
+// Use http.StripPrefix to mount it under a path.
+type Handler struct {
+	storage   storage.Storage
+	authorize Authorizer
+}
+
+type Option func(*Handler)
+
+// WithAuthorizer sets the hook called before serving each object.
+func WithAuthorizer(a Authorizer) Option {
+	return func(h *Handler) {
+		h.authorize = a
+	}
+}
+
+func New(s storage.Storage, opts ...Option) *Handler {
+	h := &Handler{
+		storage: s,
+	}
+
+	for _, o := range opts {
+		o(h)
+	}
+
+	return h
+}
+
+// ServeHTTP serves GET and HEAD requests with conditional and range requests handled by http.ServeContent.
+// Objects are read by range when the storage implements storage.Stater and storage.RangeGetter.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	key, ok := objectKey(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if h.authorize != nil {
+		if err := h.authorize(r, key); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	}
+
+	ctx := r.Context()
+
+	info, content, err := h.open(r, key)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to read object", "key", key)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if info == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if info.ContentType != "" {
+		w.Header().Set("Content-Type", info.ContentType)
+	}
+	if info.ContentDisposition != "" {
+		w.Header().Set("Content-Disposition", info.ContentDisposition)
+	}
+	w.Header().Set("ETag", etag(info))
+
+	http.ServeContent(w, r, key, info.LastModified, content)
+}
+
+// objectKey returns the cleaned key of the URL path. Directories and paths with ".." segments have no object,
+// since storages such as provider.Disk would resolve them outside of their root.
+func objectKey(urlPath string) (string, bool) {
+	if strings.HasSuffix(urlPath, "/") {
+		return "", false
+	}
+
+	for _, segment := range strings.Split(urlPath, "/") {
+		if segment == ".." {
+			return "", false
+		}
+	}
+
+	key := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if key == "" {
+		return "", false
+	}
+
+	return key, true
+}
+
+// open returns the information and the content of the object, or nil information when it does not exist.
+func (h *Handler) open(r *http.Request, key string) (*object.Info, io.ReadSeeker, error) {
+	ctx := r.Context()
+
+	st, isStater := h.storage.(storage.Stater)
+	rg, isRangeGetter := h.storage.(storage.RangeGetter)
+
+	if isStater && isRangeGetter {
+		info, err := st.Stat(ctx, key)
+		if err != nil || info == nil {
+			return nil, nil, err
+		}
+
+		return info, io.NewSectionReader(storage.NewReaderAt(ctx, rg, key), 0, info.Size), nil
+	}
+
+	data, err := h.storage.Get(ctx, key)
+	if err != nil || data == nil {
+		return nil, nil, err
+	}
+
+	info := &object.Info{
+		Key:  key,
+		Size: int64(len(data)),
+	}
+
+	if isStater {
+		i, err := st.Stat(ctx, key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if i != nil {
+			info = i
+		}
+	}
+
+	if info.ContentType == "" {
+		info.ContentType = contenttype.Detect(key, data)
+	}
+
+	if info.ETag == "" {
+		sum := md5.Sum(data)
+		info.ETag = `"` + hex.EncodeToString(sum[:]) + `"`
+	}
+
+	return info, bytes.NewReader(data), nil
+}
+
+// etag returns the ETag of the object, or a weak ETag made of its size and modification time when the storage has none.
+func etag(info *object.Info) string {
+	if info.ETag != "" {
+		if strings.HasPrefix(info.ETag, `"`) || strings.HasPrefix(info.ETag, `W/"`) {
+			return info.ETag
+		}
+
+		return `"` + info.ETag + `"`
+	}
+
+	return fmt.Sprintf(`W/"%x-%x"`, info.Size, info.LastModified.UnixNano())
+}
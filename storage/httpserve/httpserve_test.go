@@ -0,0 +1,179 @@
+package httpserve
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hatappi/go-kit/storage/option"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestHandler(t *testing.T) {
+	ctx := context.Background()
+
+	memoryProvider := provider.NewMemory()
+	_, err := memoryProvider.Save(ctx, "docs/report.txt", []byte("0123456789"),
+		option.SaveOptionWithContentType("text/plain"),
+		option.SaveOptionWithContentDisposition(`attachment; filename="report.txt"`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := New(memoryProvider, WithAuthorizer(func(r *http.Request, key string) error {
+		if r.Header.Get("Authorization") == "" {
+			return errors.New("authorization is required")
+		}
+
+		return nil
+	}))
+
+	do := func(method, target string, header map[string]string) *http.Response {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer test")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec.Result()
+	}
+
+	res := do(http.MethodGet, "/docs/report.txt", nil)
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || string(body) != "0123456789" {
+		t.Fatalf("unexpected response. status: %d, body: %s", res.StatusCode, body)
+	}
+
+	if ct := res.Header.Get("Content-Type"); ct != "text/plain" {
+		t.Fatalf("unexpected content type. %s", ct)
+	}
+
+	if cd := res.Header.Get("Content-Disposition"); cd != `attachment; filename="report.txt"` {
+		t.Fatalf("unexpected content disposition. %s", cd)
+	}
+
+	etag := res.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag is not set")
+	}
+
+	testCases := []struct {
+		name   string
+		method string
+		target string
+		header map[string]string
+
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "range",
+			method:     http.MethodGet,
+			target:     "/docs/report.txt",
+			header:     map[string]string{"Range": "bytes=2-4"},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   "234",
+		},
+		{
+			name:       "if-none-match",
+			method:     http.MethodGet,
+			target:     "/docs/report.txt",
+			header:     map[string]string{"If-None-Match": etag},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "if-modified-since",
+			method:     http.MethodGet,
+			target:     "/docs/report.txt",
+			header:     map[string]string{"If-Modified-Since": res.Header.Get("Last-Modified")},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "not found",
+			method:     http.MethodGet,
+			target:     "/docs/missing.txt",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodPost,
+			target:     "/docs/report.txt",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			res := do(tc.method, tc.target, tc.header)
+			body, _ := io.ReadAll(res.Body)
+
+			if res.StatusCode != tc.wantStatus {
+				t.Fatalf("unexpected status. expected: %d, actual: %d", tc.wantStatus, res.StatusCode)
+			}
+
+			if tc.wantBody != "" && string(body) != tc.wantBody {
+				t.Fatalf("unexpected body. %s", body)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/docs/report.txt", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("unauthorized request should be forbidden. %d", rec.Code)
+	}
+}
+
+func TestHandlerPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	d := provider.NewDisk(root)
+	if _, err := d.Save(context.Background(), "public.txt", []byte("public")); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(New(d))
+	t.Cleanup(server.Close)
+
+	testCases := []struct {
+		target     string
+		wantStatus int
+	}{
+		{target: "/public.txt", wantStatus: http.StatusOK},
+		{target: "/./public.txt", wantStatus: http.StatusOK},
+		{target: "/%2e%2e/secret.txt", wantStatus: http.StatusNotFound},
+		{target: "/dir/%2e%2e/%2e%2e/secret.txt", wantStatus: http.StatusNotFound},
+		{target: "/..%2fsecret.txt", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		res, err := http.Get(server.URL + tc.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode != tc.wantStatus {
+			t.Errorf("%s: unexpected response. status: %d, body: %s", tc.target, res.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+// FS exposes a storage as a read-only fs.FS.
+// Directories are emulated from the "/" separated object keys, so the storage has to implement Lister
+// to open or stat directories. Stat uses Stater when the storage implements it.
+type FS struct {
+	ctx     context.Context
+	storage Storage
+}
+
+var (
+	_ fs.FS         = (*FS)(nil)
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+)
+
+// NewFS returns an FS over s. ctx is used for every call to s, since fs.FS has no context.
+func NewFS(ctx context.Context, s Storage) *FS {
+	return &FS{
+		ctx:     ctx,
+		storage: s,
+	}
+}
+
+// Open opens the named file or directory.
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name != "." {
+		fi, data, err := f.openFile(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+
+		if fi != nil {
+			return &file{info: fi, Reader: bytes.NewReader(data)}, nil
+		}
+	}
+
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &dir{info: dirInfo(name), entries: entries}, nil
+}
+
+// ReadFile reads the named file.
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+
+	data, err := f.storage.Get(f.ctx, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+
+	if data == nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return data, nil
+}
+
+// ReadDir reads the named directory and returns its entries sorted by name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	return entries, nil
+}
+
+// Stat returns the information of the named file or directory.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return dirInfo(name), nil
+	}
+
+	info, err := f.stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	if info != nil {
+		return info, nil
+	}
+
+	if _, err := f.readDir(name); err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	return dirInfo(name), nil
+}
+
+// stat returns nil when name is not a file.
+func (f *FS) stat(name string) (*fileInfo, error) {
+	if _, ok := f.storage.(Stater); !ok {
+		data, err := f.storage.Get(f.ctx, name)
+		if err != nil || data == nil {
+			return nil, err
+		}
+
+		return &fileInfo{name: path.Base(name), info: object.Info{Key: name, Size: int64(len(data))}}, nil
+	}
+
+	info, err := stat(f.ctx, f.storage, name)
+	if err != nil || info == nil {
+		return nil, err
+	}
+
+	return &fileInfo{name: path.Base(name), info: *info}, nil
+}
+
+// openFile returns nil information when name is not a file.
+// Stat is checked before Get when the storage implements Stater, since storages such as provider.Disk fail to get directories.
+func (f *FS) openFile(name string) (*fileInfo, []byte, error) {
+	if _, ok := f.storage.(Stater); ok {
+		fi, err := f.stat(name)
+		if err != nil || fi == nil {
+			return nil, nil, err
+		}
+
+		data, err := f.storage.Get(f.ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the object was deleted after stat
+		if data == nil {
+			return nil, nil, fs.ErrNotExist
+		}
+
+		return fi, data, nil
+	}
+
+	data, err := f.storage.Get(f.ctx, name)
+	if err != nil || data == nil {
+		return nil, nil, err
+	}
+
+	return &fileInfo{name: path.Base(name), info: object.Info{Key: name, Size: int64(len(data))}}, data, nil
+}
+
+// readDir returns fs.ErrNotExist when no object is stored under name.
+func (f *FS) readDir(name string) ([]fs.DirEntry, error) {
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	infos, err := list(f.ctx, f.storage, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(infos) == 0 && name != "." {
+		return nil, fs.ErrNotExist
+	}
+
+	seen := map[string]bool{}
+	var entries []fs.DirEntry
+	for _, info := range infos {
+		rest := strings.TrimPrefix(info.Key, prefix)
+
+		entryName, isDir := rest, false
+		if i := strings.Index(rest, "/"); i >= 0 {
+			entryName, isDir = rest[:i], true
+		}
+
+		if entryName == "" || seen[entryName] {
+			continue
+		}
+		seen[entryName] = true
+
+		if isDir {
+			entries = append(entries, fs.FileInfoToDirEntry(dirInfo(entryName)))
+		} else {
+			entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{name: entryName, info: info}))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+// fileInfo implements fs.FileInfo. Sys returns the object.Info of files.
+type fileInfo struct {
+	name string
+	dir  bool
+	info object.Info
+}
+
+func dirInfo(name string) *fileInfo {
+	return &fileInfo{name: path.Base(name), dir: true}
+}
+
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+func (fi *fileInfo) Size() int64 {
+	return fi.info.Size
+}
+
+func (fi *fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+
+	return 0o444
+}
+
+func (fi *fileInfo) ModTime() time.Time {
+	return fi.info.LastModified
+}
+
+func (fi *fileInfo) IsDir() bool {
+	return fi.dir
+}
+
+func (fi *fileInfo) Sys() interface{} {
+	if fi.dir {
+		return nil
+	}
+
+	return &fi.info
+}
+
+// file is an opened object. It also implements io.Seeker and io.ReaderAt for http.FS.
+type file struct {
+	*bytes.Reader
+	info *fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Close() error {
+	return nil
+}
+
+type dir struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+// ReadDir follows the semantics of fs.ReadDirFile.
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+
+	return rest[:n], nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestFS(t *testing.T) {
+	ctx := context.Background()
+
+	m := provider.NewMemory()
+	for p, content := range map[string]string{
+		"index.html":         "<html></html>",
+		"css/main.css":       "body {}",
+		"js/app.js":          "console.log(1)",
+		"js/vendor/lib.js":   "lib",
+		"templates/a.tmpl":   "a",
+		"templates/b/c.tmpl": "c",
+	} {
+		if _, err := m.Save(ctx, p, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsys := NewFS(ctx, m)
+
+	if err := fstest.TestFS(fsys, "index.html", "css/main.css", "js/app.js", "js/vendor/lib.js", "templates/b/c.tmpl"); err != nil {
+		t.Fatal(err)
+	}
+
+	var walked []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		walked = append(walked, p)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{".", "css", "css/main.css", "index.html", "js", "js/app.js", "js/vendor", "js/vendor/lib.js", "templates", "templates/a.tmpl", "templates/b", "templates/b/c.tmpl"}
+	if d := cmp.Diff(want, walked); d != "" {
+		t.Errorf("walked paths were a mismatch. %s", d)
+	}
+
+	if _, err := fsys.Open("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file should not exist. %v", err)
+	}
+
+	if _, err := fsys.Stat("js/vendor/lib"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("partial key should not exist. %v", err)
+	}
+}
+
+func TestFSWithDisk(t *testing.T) {
+	d := newTestDisk(t, map[string]string{
+		"index.html":         "<html></html>",
+		"css/main.css":       "body {}",
+		"templates/b/c.tmpl": "c",
+	})
+
+	fsys := NewFS(context.Background(), d)
+
+	if err := fstest.TestFS(fsys, "index.html", "css/main.css", "templates/b/c.tmpl"); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := fsys.Open("templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() {
+		t.Errorf("templates should be a directory. %v", info.Mode())
+	}
+}
+
+func TestFSWithProviderFS(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"a.txt":     {Data: []byte("a")},
+		"dir/b.txt": {Data: []byte("b")},
+	}
+
+	fsys := NewFS(context.Background(), provider.NewFS(mapFS))
+
+	if err := fstest.TestFS(fsys, "a.txt", "dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// Limiter wraps a Storage and limits the request rate per operation and the number of in-flight requests.
+// Requests wait for the limits until their context is done.
+type Limiter struct {
+	storage Storage
+
+	defaultRate *rate.Limiter
+	rates       map[Operation]*rate.Limiter
+	sem         chan struct{}
+
+	waiting  atomic.Int64
+	inFlight atomic.Int64
+}
+
+type LimitOptionFunc func(l *Limiter)
+
+// LimitOptionWithRate limits the operation to r requests per second with bursts of up to burst requests.
+func LimitOptionWithRate(op Operation, r float64, burst int) LimitOptionFunc {
+	return func(l *Limiter) {
+		l.rates[op] = rate.NewLimiter(rate.Limit(r), burst)
+	}
+}
+
+// LimitOptionWithDefaultRate limits operations without their own rate to r requests per second in total.
+func LimitOptionWithDefaultRate(r float64, burst int) LimitOptionFunc {
+	return func(l *Limiter) {
+		l.defaultRate = rate.NewLimiter(rate.Limit(r), burst)
+	}
+}
+
+// LimitOptionWithMaxInFlight limits the number of requests running at the same time.
+// n <= 0 leaves the number unlimited.
+func LimitOptionWithMaxInFlight(n int) LimitOptionFunc {
+	return func(l *Limiter) {
+		if n <= 0 {
+			l.sem = nil
+
+			return
+		}
+
+		l.sem = make(chan struct{}, n)
+	}
+}
+
+func NewLimiter(s Storage, opts ...LimitOptionFunc) *Limiter {
+	l := &Limiter{
+		storage: s,
+		rates:   map[Operation]*rate.Limiter{},
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// QueueDepth returns the number of requests waiting for the limits.
+func (l *Limiter) QueueDepth() int {
+	return int(l.waiting.Load())
+}
+
+// InFlight returns the number of requests running.
+func (l *Limiter) InFlight() int {
+	return int(l.inFlight.Load())
+}
+
+func (l *Limiter) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	release, err := l.acquire(ctx, OperationSave)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	return l.storage.Save(ctx, filePath, data, opts...)
+}
+
+func (l *Limiter) Get(ctx context.Context, filePath string) ([]byte, error) {
+	release, err := l.acquire(ctx, OperationGet)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return l.storage.Get(ctx, filePath)
+}
+
+func (l *Limiter) Delete(ctx context.Context, filePath string) error {
+	release, err := l.acquire(ctx, OperationDelete)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return l.storage.Delete(ctx, filePath)
+}
+
+func (l *Limiter) Ping(ctx context.Context) error {
+	release, err := l.acquire(ctx, OperationPing)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return l.storage.Ping(ctx)
+}
+
+func (l *Limiter) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	rg, ok := l.storage.(RangeGetter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support range reads: %w", l.storage, ErrNotSupported)
+	}
+
+	release, err := l.acquire(ctx, OperationGetRange)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return rg.GetRange(ctx, filePath, offset, length)
+}
+
+func (l *Limiter) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	release, err := l.acquire(ctx, OperationStat)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return stat(ctx, l.storage, filePath)
+}
+
+func (l *Limiter) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	release, err := l.acquire(ctx, OperationList)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return list(ctx, l.storage, prefix)
+}
+
+func (l *Limiter) acquire(ctx context.Context, op Operation) (func(), error) {
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	r, ok := l.rates[op]
+	if !ok {
+		r = l.defaultRate
+	}
+
+	if r != nil {
+		if err := r.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.sem != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case l.sem <- struct{}{}:
+		}
+	}
+
+	l.inFlight.Add(1)
+
+	return func() {
+		l.inFlight.Add(-1)
+
+		if l.sem != nil {
+			<-l.sem
+		}
+	}, nil
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+type blockingStorage struct {
+	Storage
+
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingStorage) Get(ctx context.Context, filePath string) ([]byte, error) {
+	b.started <- struct{}{}
+	<-b.release
+
+	return nil, nil
+}
+
+func TestLimiterMaxInFlight(t *testing.T) {
+	bs := &blockingStorage{
+		Storage: provider.NewMemory(),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	l := NewLimiter(bs, LimitOptionWithMaxInFlight(1))
+
+	ctx := context.Background()
+
+	done := make(chan error, 2)
+	go func() {
+		_, err := l.Get(ctx, "a")
+		done <- err
+	}()
+
+	<-bs.started
+
+	go func() {
+		_, err := l.Get(ctx, "b")
+		done <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for l.QueueDepth() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("second request was not queued. queue depth: %d", l.QueueDepth())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if l.InFlight() != 1 {
+		t.Fatalf("unexpected in-flight requests. %d", l.InFlight())
+	}
+
+	bs.release <- struct{}{}
+	<-bs.started
+	bs.release <- struct{}{}
+
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if l.QueueDepth() != 0 || l.InFlight() != 0 {
+		t.Fatalf("unexpected counters. queue depth: %d, in-flight: %d", l.QueueDepth(), l.InFlight())
+	}
+}
+
+func TestLimiterUnlimitedInFlight(t *testing.T) {
+	bs := &blockingStorage{
+		Storage: provider.NewMemory(),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	l := NewLimiter(bs, LimitOptionWithMaxInFlight(0))
+
+	ctx := context.Background()
+
+	done := make(chan error, 2)
+	for _, key := range []string{"a", "b"} {
+		key := key
+		go func() {
+			_, err := l.Get(ctx, key)
+			done <- err
+		}()
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-bs.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("requests should not be limited")
+		}
+	}
+
+	if l.InFlight() != 2 {
+		t.Fatalf("unexpected in-flight requests. %d", l.InFlight())
+	}
+
+	for i := 0; i < 2; i++ {
+		bs.release <- struct{}{}
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	l := NewLimiter(provider.NewMemory(), LimitOptionWithRate(OperationSave, 1, 1))
+
+	ctx := context.Background()
+	if _, err := l.Save(ctx, "a", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := l.Save(ctx, "b", []byte("test")); err == nil {
+		t.Fatal("second save should exceed the deadline")
+	}
+
+	// other operations are not limited
+	if _, err := l.Get(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+// Lister is implemented by storages that can list objects.
+type Lister interface {
+	List(ctx context.Context, prefix string) ([]object.Info, error)
+}
+
+func list(ctx context.Context, s Storage, prefix string) ([]object.Info, error) {
+	l, ok := s.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support listing: %w", s, ErrNotSupported)
+	}
+
+	return l.List(ctx, prefix)
+}
@@ -0,0 +1,373 @@
+// Package lock provides leases coordinated through a storage.
+//
+// A lease is an object holding its owner and expiry, created only when it does not exist and renewed or taken over
+// only when it was not changed since it was read, by storage.ConditionalWriter.
+// Expiry is judged by the clock of the acquirer, so the TTL should be much longer than the clock skew between hosts.
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage"
+)
+
+const (
+	defaultKeyPrefix     = ".locks"
+	defaultTTL           = 30 * time.Second
+	defaultRetryInterval = time.Second
+)
+
+var (
+	// ErrLocked is returned by TryAcquire when the lease is held by another owner.
+	ErrLocked = errors.New("lock is held by another owner")
+	// ErrLost is returned by Release when the lease expired or was taken over.
+	ErrLost = errors.New("lease was lost")
+)
+
+// Locker acquires leases stored in a storage.
+type Locker struct {
+	storage storage.ConditionalWriter
+
+	keyPrefix     string
+	owner         string
+	ttl           time.Duration
+	renewInterval time.Duration
+	retryInterval time.Duration
+	clock         func() time.Time
+}
+
+type Option func(*Locker)
+
+// WithKeyPrefix sets the prefix of the lock objects. It defaults to ".locks".
+func WithKeyPrefix(prefix string) Option {
+	return func(l *Locker) {
+		l.keyPrefix = prefix
+	}
+}
+
+// WithOwner sets the owner recorded in the lock objects. It defaults to the hostname and the process ID.
+func WithOwner(owner string) Option {
+	return func(l *Locker) {
+		l.owner = owner
+	}
+}
+
+// WithTTL sets how long a lease is valid without renewal. It defaults to 30 seconds.
+func WithTTL(ttl time.Duration) Option {
+	return func(l *Locker) {
+		l.ttl = ttl
+	}
+}
+
+// WithRenewInterval sets how often a lease is renewed. It defaults to a third of the TTL.
+func WithRenewInterval(interval time.Duration) Option {
+	return func(l *Locker) {
+		l.renewInterval = interval
+	}
+}
+
+// WithRetryInterval sets how often Acquire retries while the lease is held by another owner. It defaults to 1 second.
+func WithRetryInterval(interval time.Duration) Option {
+	return func(l *Locker) {
+		l.retryInterval = interval
+	}
+}
+
+// WithClock sets the function returning the current time, by which leases are stamped and judged expired.
+// It defaults to time.Now.
+func WithClock(clock func() time.Time) Option {
+	return func(l *Locker) {
+		l.clock = clock
+	}
+}
+
+// New returns a Locker. s has to implement storage.ConditionalWriter.
+func New(s storage.Storage, opts ...Option) (*Locker, error) {
+	cw, ok := s.(storage.ConditionalWriter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support conditional writes: %w", s, storage.ErrNotSupported)
+	}
+
+	l := &Locker{
+		storage:       cw,
+		keyPrefix:     defaultKeyPrefix,
+		owner:         defaultOwner(),
+		ttl:           defaultTTL,
+		retryInterval: defaultRetryInterval,
+		clock:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	if l.renewInterval <= 0 {
+		l.renewInterval = l.ttl / 3
+	}
+
+	return l, nil
+}
+
+func defaultOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return hostname + "-" + strconv.Itoa(os.Getpid())
+}
+
+// record is the content of a lock object.
+type record struct {
+	Owner      string    `json:"owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Acquire waits until the lease of name is acquired or ctx is done.
+func (l *Locker) Acquire(ctx context.Context, name string) (*Lease, error) {
+	for {
+		lease, err := l.TryAcquire(ctx, name)
+		if !errors.Is(err, ErrLocked) {
+			return lease, err
+		}
+
+		select {
+		case <-time.After(l.retryInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// TryAcquire acquires the lease of name, taking it over when it expired.
+// It returns ErrLocked when the lease is held by another owner.
+// The lease is renewed in background until it is released.
+func (l *Locker) TryAcquire(ctx context.Context, name string) (*Lease, error) {
+	key := path.Join(l.keyPrefix, name)
+
+	acquiredAt := l.clock()
+	data, err := l.marshal(acquiredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	etag, err := l.storage.SaveIfAbsent(ctx, key, data)
+	if errors.Is(err, storage.ErrPreconditionFailed) {
+		etag, err = l.takeOver(ctx, key, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newLease(l, name, key, etag, acquiredAt), nil
+}
+
+// takeOver replaces the lock object when it expired.
+func (l *Locker) takeOver(ctx context.Context, key string, data []byte) (string, error) {
+	current, etag, err := l.storage.GetWithETag(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	// the lease was released after SaveIfAbsent failed
+	if current == nil {
+		etag, err := l.storage.SaveIfAbsent(ctx, key, data)
+		if errors.Is(err, storage.ErrPreconditionFailed) {
+			return "", fmt.Errorf("%s was acquired concurrently: %w", key, ErrLocked)
+		}
+
+		return etag, err
+	}
+
+	// an empty or corrupted record can never be renewed by its owner, so it is stale as well as an expired one
+	var r record
+	if err := json.Unmarshal(current, &r); err == nil && l.clock().Before(r.ExpiresAt) {
+		return "", fmt.Errorf("%s is held by %s until %s: %w", key, r.Owner, r.ExpiresAt.Format(time.RFC3339), ErrLocked)
+	}
+
+	etag, err = l.storage.SaveIfMatch(ctx, key, data, etag)
+	if errors.Is(err, storage.ErrPreconditionFailed) {
+		return "", fmt.Errorf("%s was taken over concurrently: %w", key, ErrLocked)
+	}
+
+	return etag, err
+}
+
+func (l *Locker) marshal(acquiredAt time.Time) ([]byte, error) {
+	return json.Marshal(record{
+		Owner:      l.owner,
+		AcquiredAt: acquiredAt,
+		ExpiresAt:  l.clock().Add(l.ttl),
+	})
+}
+
+// Lease is an acquired lock.
+type Lease struct {
+	locker     *Locker
+	name       string
+	key        string
+	acquiredAt time.Time
+
+	mu        sync.Mutex
+	etag      string
+	expiresAt time.Time
+	released  bool
+
+	lost     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	lostOnce sync.Once
+}
+
+func newLease(l *Locker, name, key, etag string, acquiredAt time.Time) *Lease {
+	lease := &Lease{
+		locker:     l,
+		name:       name,
+		key:        key,
+		acquiredAt: acquiredAt,
+		etag:       etag,
+		expiresAt:  acquiredAt.Add(l.ttl),
+		lost:       make(chan struct{}),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	go lease.renewLoop()
+
+	return lease
+}
+
+// Name returns the name passed to Acquire.
+func (l *Lease) Name() string {
+	return l.name
+}
+
+// Lost is closed when the lease expired or was taken over. Work guarded by the lease should stop then.
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release stops the renewal and deletes the lock object.
+// It returns ErrLost when the lease had been lost.
+func (l *Lease) Release(ctx context.Context) error {
+	l.mu.Lock()
+	if l.released {
+		l.mu.Unlock()
+
+		return nil
+	}
+	l.released = true
+	l.mu.Unlock()
+
+	close(l.stop)
+	<-l.done
+
+	select {
+	case <-l.lost:
+		return ErrLost
+	default:
+	}
+
+	l.mu.Lock()
+	etag := l.etag
+	l.mu.Unlock()
+
+	err := l.locker.storage.DeleteIfMatch(ctx, l.key, etag)
+	if errors.Is(err, storage.ErrPreconditionFailed) {
+		l.markLost()
+
+		return ErrLost
+	}
+
+	return err
+}
+
+func (l *Lease) renewLoop() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.locker.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		l.mu.Lock()
+		expiry := time.NewTimer(l.expiresAt.Sub(l.locker.clock()))
+		l.mu.Unlock()
+
+		select {
+		case <-l.stop:
+			expiry.Stop()
+
+			return
+		case <-expiry.C:
+			l.markLost()
+
+			return
+		case <-ticker.C:
+			expiry.Stop()
+		}
+
+		if err := l.renew(); err != nil {
+			if errors.Is(err, ErrLost) {
+				return
+			}
+
+			log.FromContext(context.Background()).Error(err, "failed to renew the lease", "key", l.key)
+		}
+	}
+}
+
+// renew extends the expiry. The lease is lost when the lock object was changed or it expired before renewal succeeded.
+func (l *Lease) renew() error {
+	l.mu.Lock()
+	etag, expiresAt := l.etag, l.expiresAt
+	l.mu.Unlock()
+
+	if !l.locker.clock().Before(expiresAt) {
+		l.markLost()
+
+		return ErrLost
+	}
+
+	data, err := l.locker.marshal(l.acquiredAt)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), expiresAt)
+	defer cancel()
+
+	renewedAt := l.locker.clock()
+
+	newETag, err := l.locker.storage.SaveIfMatch(ctx, l.key, data, etag)
+	if err != nil {
+		if errors.Is(err, storage.ErrPreconditionFailed) {
+			l.markLost()
+
+			return ErrLost
+		}
+
+		return err
+	}
+
+	l.mu.Lock()
+	l.etag = newETag
+	l.expiresAt = renewedAt.Add(l.locker.ttl)
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *Lease) markLost() {
+	l.lostOnce.Do(func() {
+		close(l.lost)
+	})
+}
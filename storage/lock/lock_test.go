@@ -0,0 +1,240 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestLocker(t *testing.T) {
+	testCases := []struct {
+		name    string
+		storage func(t *testing.T) storage.Storage
+	}{
+		{
+			name: "disk",
+			storage: func(t *testing.T) storage.Storage {
+				return provider.NewDisk(t.TempDir())
+			},
+		},
+		{
+			name: "memory",
+			storage: func(t *testing.T) storage.Storage {
+				return provider.NewMemory()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := tc.storage(t)
+
+			a := newTestLocker(t, s, "a")
+			b := newTestLocker(t, s, "b")
+
+			lease, err := a.TryAcquire(ctx, "job")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := b.TryAcquire(ctx, "job"); !errors.Is(err, ErrLocked) {
+				t.Fatalf("held lease should not be acquired. %v", err)
+			}
+
+			// the lease outlives its TTL by renewal
+			time.Sleep(300 * time.Millisecond)
+
+			if _, err := b.TryAcquire(ctx, "job"); !errors.Is(err, ErrLocked) {
+				t.Fatalf("renewed lease should not be acquired. %v", err)
+			}
+
+			if err := lease.Release(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			lease, err = b.TryAcquire(ctx, "job")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := lease.Release(ctx); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestLockerTakeOverStaleLock(t *testing.T) {
+	ctx := context.Background()
+	s := provider.NewMemory()
+
+	data, err := json.Marshal(record{
+		Owner:     "crashed",
+		ExpiresAt: time.Now().Add(-time.Second),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Save(ctx, ".locks/job", data); err != nil {
+		t.Fatal(err)
+	}
+
+	lease, err := newTestLocker(t, s, "a").TryAcquire(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lease.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockerClock(t *testing.T) {
+	ctx := context.Background()
+	s := provider.NewMemory()
+
+	held, err := newTestLocker(t, s, "a").TryAcquire(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Release(ctx)
+
+	if _, err := newTestLocker(t, s, "b").TryAcquire(ctx, "job"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("lease should be held. %v", err)
+	}
+
+	l, err := New(s, WithOwner("b"), WithClock(func() time.Time { return time.Now().Add(time.Hour) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lease, err := l.TryAcquire(ctx, "job")
+	if err != nil {
+		t.Fatalf("lease expired by the clock should be taken over. %v", err)
+	}
+	defer lease.Release(ctx)
+}
+
+func TestLockerTakeOverEmptyLock(t *testing.T) {
+	ctx := context.Background()
+
+	for name, content := range map[string]string{"empty": "", "corrupted": "{"} {
+		content := content
+
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := provider.NewDisk(dir)
+
+			// left by a crash of an older version which created the file before writing it
+			if err := os.MkdirAll(filepath.Join(dir, ".locks"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, ".locks", "job"), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			lease, err := newTestLocker(t, s, "a").TryAcquire(ctx, "job")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := lease.Release(ctx); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestLeaseLost(t *testing.T) {
+	ctx := context.Background()
+	s := provider.NewMemory()
+
+	lease, err := newTestLocker(t, s, "a").TryAcquire(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// another owner overwrites the lock object
+	if _, err := s.Save(ctx, ".locks/job", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lease was not lost")
+	}
+
+	if err := lease.Release(ctx); !errors.Is(err, ErrLost) {
+		t.Fatalf("release of lost lease should fail. %v", err)
+	}
+
+	data, err := s.Get(ctx, ".locks/job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "{}" {
+		t.Fatalf("lock object of another owner should not be deleted. %s", data)
+	}
+}
+
+func TestLockerAcquireWaits(t *testing.T) {
+	ctx := context.Background()
+	s := provider.NewMemory()
+
+	lease, err := newTestLocker(t, s, "a").TryAcquire(ctx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		lease.Release(ctx)
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	lease, err = newTestLocker(t, s, "b").Acquire(waitCtx, "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lease.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewWithoutConditionalWriter(t *testing.T) {
+	if _, err := New(storage.ReadOnly(provider.NewMemory())); !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("storage without conditional writes should not be supported. %v", err)
+	}
+}
+
+func newTestLocker(t *testing.T, s storage.Storage, owner string) *Locker {
+	t.Helper()
+
+	l, err := New(s,
+		WithOwner(owner),
+		WithTTL(100*time.Millisecond),
+		WithRenewInterval(20*time.Millisecond),
+		WithRetryInterval(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return l
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+)
+
+// multiError aggregates the errors of operations which continue after a failure.
+// errors.Is and errors.As match any of the errors.
+type multiError []error
+
+// joinErrors returns nil when every error is nil, the error itself when only one is not nil, or a multiError.
+func joinErrors(errs ...error) error {
+	var me multiError
+	for _, err := range errs {
+		if err != nil {
+			me = append(me, err)
+		}
+	}
+
+	switch len(me) {
+	case 0:
+		return nil
+	case 1:
+		return me[0]
+	default:
+		return me
+	}
+}
+
+func (me multiError) Error() string {
+	msgs := make([]string, 0, len(me))
+	for _, err := range me {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (me multiError) Is(target error) bool {
+	for _, err := range me {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (me multiError) As(target any) bool {
+	for _, err := range me {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestJoinErrors(t *testing.T) {
+	errA := errors.New("a")
+
+	if err := joinErrors(nil, nil); err != nil {
+		t.Errorf("nil errors should be joined to nil. %v", err)
+	}
+
+	if err := joinErrors(nil, errA); err != errA {
+		t.Errorf("a single error should be returned as is. %v", err)
+	}
+
+	err := joinErrors(errA, nil, &fs.PathError{Op: "open", Path: "b", Err: fs.ErrNotExist})
+	if err.Error() != "a\nopen b: file does not exist" {
+		t.Errorf("unexpected message. %s", err)
+	}
+
+	if !errors.Is(err, errA) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("joined errors should match each error. %v", err)
+	}
+
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "b" {
+		t.Errorf("joined errors should be converted to each error. %v", err)
+	}
+}
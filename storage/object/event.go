@@ -0,0 +1,16 @@
+package object
+
+type EventType string
+
+const (
+	EventTypeCreated EventType = "created"
+	EventTypeUpdated EventType = "updated"
+	EventTypeDeleted EventType = "deleted"
+)
+
+// Event describes a change of an object.
+// Info only has Key set for deleted objects.
+type Event struct {
+	Type EventType
+	Info Info
+}
@@ -0,0 +1,13 @@
+package object
+
+import "time"
+
+// Info describes a stored object.
+type Info struct {
+	Key                string
+	Size               int64
+	LastModified       time.Time
+	ETag               string
+	ContentType        string
+	ContentDisposition string
+}
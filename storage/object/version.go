@@ -0,0 +1,12 @@
+package object
+
+import "time"
+
+// Version describes a version of an object.
+type Version struct {
+	VersionID      string
+	IsLatest       bool
+	IsDeleteMarker bool
+	Size           int64
+	LastModified   time.Time
+}
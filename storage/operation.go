@@ -0,0 +1,14 @@
+package storage
+
+// Operation is a method of Storage and its optional interfaces.
+type Operation string
+
+const (
+	OperationSave     Operation = "save"
+	OperationGet      Operation = "get"
+	OperationGetRange Operation = "get_range"
+	OperationDelete   Operation = "delete"
+	OperationStat     Operation = "stat"
+	OperationList     Operation = "list"
+	OperationPing     Operation = "ping"
+)
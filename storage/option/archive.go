@@ -0,0 +1,30 @@
+package option
+
+type ArchiveOption struct {
+	// MaxFiles is the maximum number of files extracted from an archive.
+	MaxFiles int
+	// MaxFileSize is the maximum uncompressed size of a file extracted from an archive.
+	MaxFileSize int64
+	// MaxTotalSize is the maximum uncompressed size of all files extracted from an archive.
+	MaxTotalSize int64
+}
+
+type ArchiveOptionFunc func(opt *ArchiveOption)
+
+func ArchiveOptionWithMaxFiles(n int) ArchiveOptionFunc {
+	return func(opt *ArchiveOption) {
+		opt.MaxFiles = n
+	}
+}
+
+func ArchiveOptionWithMaxFileSize(size int64) ArchiveOptionFunc {
+	return func(opt *ArchiveOption) {
+		opt.MaxFileSize = size
+	}
+}
+
+func ArchiveOptionWithMaxTotalSize(size int64) ArchiveOptionFunc {
+	return func(opt *ArchiveOption) {
+		opt.MaxTotalSize = size
+	}
+}
@@ -1,8 +1,11 @@
 package option
 
+import "time"
+
 type SaveOption struct {
 	ContentType        *string
 	ContentDisposition *string
+	TTL                *time.Duration
 }
 
 type SaveOptionFunc func(opt *SaveOption)
@@ -18,3 +21,10 @@ func SaveOptionWithContentDisposition(cd string) SaveOptionFunc {
 		opt.ContentDisposition = &cd
 	}
 }
+
+// SaveOptionWithTTL sets the duration after which the saved object expires.
+func SaveOptionWithTTL(ttl time.Duration) SaveOptionFunc {
+	return func(opt *SaveOption) {
+		opt.TTL = &ttl
+	}
+}
@@ -0,0 +1,48 @@
+package option
+
+// SyncComparison decides how Sync detects changed objects.
+type SyncComparison int
+
+const (
+	// SyncCompareChecksum copies objects whose size or checksum differs.
+	SyncCompareChecksum SyncComparison = iota
+	// SyncCompareSize copies objects whose size differs.
+	SyncCompareSize
+	// SyncCompareModTime copies objects whose size differs or whose source is newer than the destination.
+	SyncCompareModTime
+)
+
+type SyncOption struct {
+	Comparison       SyncComparison
+	Concurrency      int
+	DeleteExtraneous bool
+	DryRun           bool
+}
+
+type SyncOptionFunc func(opt *SyncOption)
+
+func SyncOptionWithComparison(c SyncComparison) SyncOptionFunc {
+	return func(opt *SyncOption) {
+		opt.Comparison = c
+	}
+}
+
+func SyncOptionWithConcurrency(n int) SyncOptionFunc {
+	return func(opt *SyncOption) {
+		opt.Concurrency = n
+	}
+}
+
+// SyncOptionWithDeleteExtraneous deletes destination objects which do not exist in the source.
+func SyncOptionWithDeleteExtraneous() SyncOptionFunc {
+	return func(opt *SyncOption) {
+		opt.DeleteExtraneous = true
+	}
+}
+
+// SyncOptionWithDryRun only reports what would be copied and deleted.
+func SyncOptionWithDryRun() SyncOptionFunc {
+	return func(opt *SyncOption) {
+		opt.DryRun = true
+	}
+}
@@ -0,0 +1,40 @@
+package option
+
+// TransferProgress is reported after each file of UploadDir and DownloadPrefix.
+type TransferProgress struct {
+	Key   string
+	Bytes int64
+	Err   error
+
+	Done  int
+	Total int
+}
+
+type TransferOption struct {
+	Concurrency int
+	Progress    func(TransferProgress)
+	Resume      bool
+}
+
+type TransferOptionFunc func(opt *TransferOption)
+
+func TransferOptionWithConcurrency(n int) TransferOptionFunc {
+	return func(opt *TransferOption) {
+		opt.Concurrency = n
+	}
+}
+
+// TransferOptionWithProgress calls fn after each file is transferred or fails. fn may be called concurrently.
+func TransferOptionWithProgress(fn func(TransferProgress)) TransferOptionFunc {
+	return func(opt *TransferOption) {
+		opt.Progress = fn
+	}
+}
+
+// TransferOptionWithResume skips files which already exist in the destination with the same size,
+// so that a failed transfer can be resumed by running it again.
+func TransferOptionWithResume() TransferOptionFunc {
+	return func(opt *TransferOption) {
+		opt.Resume = true
+	}
+}
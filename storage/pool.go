@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// workerPool runs functions with bounded concurrency.
+type workerPool struct {
+	ctx context.Context
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+func newWorkerPool(ctx context.Context, concurrency int) *workerPool {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	return &workerPool{
+		ctx: ctx,
+		sem: make(chan struct{}, concurrency),
+	}
+}
+
+// Go runs fn once a worker is free. fn is not run when the context is canceled while waiting.
+func (p *workerPool) Go(fn func(ctx context.Context)) {
+	select {
+	case <-p.ctx.Done():
+		return
+	case p.sem <- struct{}{}:
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.sem }()
+
+		fn(p.ctx)
+	}()
+}
+
+// Wait waits for all running functions and returns the context error if the context was canceled.
+func (p *workerPool) Wait() error {
+	p.wg.Wait()
+
+	return p.ctx.Err()
+}
@@ -3,16 +3,29 @@ package provider
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/object"
 	"github.com/hatappi/go-kit/storage/option"
 )
 
+// ErrReservedKey is returned by Disk for keys under the directories it keeps its bookkeeping in,
+// which are .versions, .expires and .metadata under the root directory.
+var ErrReservedKey = errors.New("key is reserved")
+
 type Disk struct {
 	rootDir string
+
+	contentTypeDetector *contenttype.Detector
+	clock               func() time.Time
 }
 
 func NewDisk(root string) *Disk {
@@ -27,6 +40,10 @@ func (d *Disk) Save(ctx context.Context, filePath string, data []byte, opts ...o
 		opt(&saveOpt)
 	}
 
+	if err := d.checkKey(filePath); err != nil {
+		return "", err
+	}
+
 	fullPath := d.fileFullPath(filePath)
 
 	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
@@ -44,10 +61,18 @@ func (d *Disk) Save(ctx context.Context, filePath string, data []byte, opts ...o
 		return "", err
 	}
 
+	if err := d.writeSidecars(filePath, &saveOpt, contentType(&saveOpt, d.contentTypeDetector, filePath, data)); err != nil {
+		return "", err
+	}
+
 	return fullPath, nil
 }
 
 func (d *Disk) Get(ctx context.Context, filePath string) ([]byte, error) {
+	if err := d.checkKey(filePath); err != nil {
+		return nil, err
+	}
+
 	fullPath := d.fileFullPath(filePath)
 
 	_, err := os.Stat(fullPath)
@@ -63,11 +88,125 @@ func (d *Disk) Get(ctx context.Context, filePath string) ([]byte, error) {
 	return raw, nil
 }
 
+// GetRange returns length bytes of the file starting at offset.
+// The returned slice is shorter than length when the file ends before the range does.
+func (d *Disk) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if offset < 0 || length <= 0 {
+		return nil, fmt.Errorf("invalid range. offset: %d, length: %d", offset, length)
+	}
+
+	if err := d.checkKey(filePath); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(d.fileFullPath(filePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, length)
+	n, err := file.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// Stat returns the information of the file. It returns nil when the file does not exist.
+func (d *Disk) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	if err := d.checkKey(filePath); err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(d.fileFullPath(filePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return nil, nil
+	}
+
+	info, err := d.objectInfo(filePath, fi)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// List returns files whose path starts with prefix, sorted by path.
+func (d *Disk) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	var infos []object.Info
+	err := filepath.Walk(d.prefixDir(prefix), func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if d.isInternalPath(p) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		key, ok := d.fileKey(p)
+		if !ok || !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		oi, err := d.objectInfo(key, info)
+		if err != nil {
+			return err
+		}
+
+		infos = append(infos, oi)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
 func (d *Disk) Delete(ctx context.Context, filePath string) error {
+	if err := d.checkKey(filePath); err != nil {
+		return err
+	}
+
 	if err := os.Remove(d.fileFullPath(filePath)); err != nil {
 		return err
 	}
 
+	if err := d.writeExpiry(filePath, nil); err != nil {
+		return err
+	}
+
+	if err := d.writeMetadata(filePath, diskMetadata{}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -95,6 +234,73 @@ func (d *Disk) Ping(ctx context.Context) error {
 	return nil
 }
 
+// prefixDir returns the deepest directory containing all files whose path starts with prefix.
+func (d *Disk) prefixDir(prefix string) string {
+	dir := d.fileFullPath(prefix)
+	if prefix == "" || strings.HasSuffix(prefix, "/") {
+		return dir
+	}
+
+	return filepath.Dir(dir)
+}
+
 func (d *Disk) fileFullPath(filePath string) string {
 	return path.Join(d.rootDir, filePath)
 }
+
+// fileKey returns the path of the file relative to the root directory.
+// It returns false for files Disk uses internally.
+func (d *Disk) fileKey(fullPath string) (string, bool) {
+	if d.isInternalPath(fullPath) {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(d.rootDir, fullPath)
+	if err != nil {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
+
+// checkKey returns ErrReservedKey when filePath is in the directories Disk uses internally.
+func (d *Disk) checkKey(filePath string) error {
+	if d.isInternalPath(d.fileFullPath(filePath)) {
+		return fmt.Errorf("%s: %w", filePath, ErrReservedKey)
+	}
+
+	return nil
+}
+
+func (d *Disk) isInternalPath(fullPath string) bool {
+	rel, err := filepath.Rel(d.rootDir, fullPath)
+	if err != nil {
+		return false
+	}
+
+	top := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+
+	return top == versionsDir || top == expiresDir || top == metadataDir
+}
+
+// objectInfo returns the information of the file with its metadata.
+func (d *Disk) objectInfo(key string, fi os.FileInfo) (object.Info, error) {
+	md, err := d.readMetadata(key)
+	if err != nil {
+		return object.Info{}, err
+	}
+
+	info := fileInfo(key, fi)
+	info.ContentType = md.ContentType
+	info.ContentDisposition = md.ContentDisposition
+
+	return info, nil
+}
+
+func fileInfo(key string, fi os.FileInfo) object.Info {
+	return object.Info{
+		Key:          key,
+		Size:         fi.Size(),
+		LastModified: fi.ModTime(),
+	}
+}
@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// Compose saves the concatenation of the sources to filePath by appending the source files one by one.
+// The content type is not detected; set it by option.SaveOptionWithContentType if needed.
+func (d *Disk) Compose(ctx context.Context, filePath string, sources []string, opts ...option.SaveOptionFunc) (string, error) {
+	var saveOpt option.SaveOption
+	for _, opt := range opts {
+		opt(&saveOpt)
+	}
+
+	if err := d.checkKey(filePath); err != nil {
+		return "", err
+	}
+
+	for _, src := range sources {
+		if err := d.checkKey(src); err != nil {
+			return "", err
+		}
+	}
+
+	fullPath := d.fileFullPath(filePath)
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return "", err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(fullPath), ".compose-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := tmp.Chmod(0644); err != nil {
+		return "", err
+	}
+
+	for _, src := range sources {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		if err := appendFile(tmp, d.fileFullPath(src)); err != nil {
+			return "", fmt.Errorf("failed to append %s. %w", src, err)
+		}
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return "", err
+	}
+
+	if err := d.writeSidecars(filePath, &saveOpt, saveOpt.ContentType); err != nil {
+		return "", err
+	}
+
+	return fullPath, nil
+}
+
+func appendFile(dst io.Writer, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(dst, f)
+
+	return err
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiskCompose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	diskProvider := &Disk{
+		rootDir: dir,
+	}
+
+	ctx := context.Background()
+	for p, content := range map[string]string{"chunks/0": "foo", "chunks/1": "bar"} {
+		if _, err := diskProvider.Save(ctx, p, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	savedPath, err := diskProvider.Compose(ctx, "out/test.txt", []string{"chunks/0", "chunks/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if savedPath != path.Join(dir, "out/test.txt") {
+		t.Fatalf("unexpected saved path. %s", savedPath)
+	}
+
+	actual, err := ioutil.ReadFile(savedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]byte("foobar"), actual); d != "" {
+		t.Fatalf("unexpected contents. %s", d)
+	}
+
+	if _, err := diskProvider.Compose(ctx, "out/missing.txt", []string{"chunks/missing"}); err == nil {
+		t.Fatal("missing source should fail")
+	}
+
+	if _, err := os.Stat(path.Join(dir, "out/missing.txt")); !os.IsNotExist(err) {
+		t.Fatal("failed compose should not leave the file")
+	}
+}
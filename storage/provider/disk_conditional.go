@@ -0,0 +1,206 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// GetWithETag returns the data and the ETag of the file. The data is nil when the file does not exist.
+// The file is read under a shared flock so that it is not observed while a conditional write is in progress.
+func (d *Disk) GetWithETag(ctx context.Context, filePath string) ([]byte, string, error) {
+	f, err := d.lockFile(filePath, os.O_RDONLY, false)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, "", nil
+		}
+
+		return nil, "", err
+	}
+	defer d.unlockFile(f)
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, contentETag(data), nil
+}
+
+// SaveIfAbsent writes the data to a temporary file and links it to the path, failing with ErrPreconditionFailed
+// when the file exists. The file only appears with its whole data, so readers and crashes never leave it empty.
+func (d *Disk) SaveIfAbsent(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	if err := d.checkKey(filePath); err != nil {
+		return "", err
+	}
+
+	fullPath := d.fileFullPath(filePath)
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return "", err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(fullPath), ".conditional-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := tmp.Chmod(0644); err != nil {
+		return "", err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.Link(tmp.Name(), fullPath); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return "", ErrPreconditionFailed
+		}
+
+		return "", err
+	}
+
+	if err := d.writeConditionalSidecars(filePath, data, opts...); err != nil {
+		return "", err
+	}
+
+	return contentETag(data), nil
+}
+
+// SaveIfMatch replaces the file under an exclusive flock only when its ETag equals etag.
+// It returns ErrPreconditionFailed otherwise.
+func (d *Disk) SaveIfMatch(ctx context.Context, filePath string, data []byte, etag string, opts ...option.SaveOptionFunc) (string, error) {
+	f, err := d.lockMatchingFile(filePath, etag)
+	if err != nil {
+		return "", err
+	}
+	defer d.unlockFile(f)
+
+	if err := f.Truncate(0); err != nil {
+		return "", err
+	}
+
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return "", err
+	}
+
+	if err := d.writeConditionalSidecars(filePath, data, opts...); err != nil {
+		return "", err
+	}
+
+	return contentETag(data), nil
+}
+
+// DeleteIfMatch deletes the file under an exclusive flock only when its ETag equals etag.
+// It returns ErrPreconditionFailed otherwise.
+func (d *Disk) DeleteIfMatch(ctx context.Context, filePath string, etag string) error {
+	f, err := d.lockMatchingFile(filePath, etag)
+	if err != nil {
+		return err
+	}
+	defer d.unlockFile(f)
+
+	if err := os.Remove(f.Name()); err != nil {
+		return err
+	}
+
+	if err := d.writeExpiry(filePath, nil); err != nil {
+		return err
+	}
+
+	return d.writeMetadata(filePath, diskMetadata{})
+}
+
+// lockMatchingFile returns the file locked exclusively when its ETag equals etag.
+func (d *Disk) lockMatchingFile(filePath string, etag string) (*os.File, error) {
+	f, err := d.lockFile(filePath, os.O_RDWR, true)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrPreconditionFailed
+		}
+
+		return nil, err
+	}
+
+	current, err := io.ReadAll(f)
+	if err != nil {
+		d.unlockFile(f)
+
+		return nil, err
+	}
+
+	if contentETag(current) != etag {
+		d.unlockFile(f)
+
+		return nil, ErrPreconditionFailed
+	}
+
+	return f, nil
+}
+
+// lockFile opens and flocks the file. Since the file may be removed or replaced while waiting for the lock,
+// it is reopened until the locked file is the one at the path.
+func (d *Disk) lockFile(filePath string, flag int, exclusive bool) (*os.File, error) {
+	if err := d.checkKey(filePath); err != nil {
+		return nil, err
+	}
+
+	fullPath := d.fileFullPath(filePath)
+
+	for {
+		f, err := os.OpenFile(fullPath, flag, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := flock(f, exclusive); err != nil {
+			f.Close()
+
+			return nil, err
+		}
+
+		locked, err := f.Stat()
+		if err != nil {
+			d.unlockFile(f)
+
+			return nil, err
+		}
+
+		current, err := os.Stat(fullPath)
+		if err == nil && os.SameFile(locked, current) {
+			return f, nil
+		}
+
+		d.unlockFile(f)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (d *Disk) unlockFile(f *os.File) {
+	funlock(f)
+	f.Close()
+}
+
+func (d *Disk) writeConditionalSidecars(filePath string, data []byte, opts ...option.SaveOptionFunc) error {
+	var saveOpt option.SaveOption
+	for _, opt := range opts {
+		opt(&saveOpt)
+	}
+
+	return d.writeSidecars(filePath, &saveOpt, contentType(&saveOpt, d.contentTypeDetector, filePath, data))
+}
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestDiskConditional(t *testing.T) {
+	ctx := context.Background()
+	diskProvider := NewDisk(t.TempDir())
+
+	etag, err := diskProvider.SaveIfAbsent(ctx, "dir/test.txt", []byte("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := diskProvider.SaveIfAbsent(ctx, "dir/test.txt", []byte("v2")); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("existing file should not be saved. %v", err)
+	}
+
+	infos, err := diskProvider.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Key != "dir/test.txt" {
+		t.Fatalf("temporary files should be removed. %+v", infos)
+	}
+
+	data, currentETag, err := diskProvider.GetWithETag(ctx, "dir/test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "v1" || currentETag != etag {
+		t.Fatalf("unexpected file. data: %s, etag: %s", data, currentETag)
+	}
+
+	if _, err := diskProvider.SaveIfMatch(ctx, "dir/test.txt", []byte("v2"), "invalid"); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("file should not be saved with a mismatched etag. %v", err)
+	}
+
+	etag, err = diskProvider.SaveIfMatch(ctx, "dir/test.txt", []byte("v2"), etag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := diskProvider.DeleteIfMatch(ctx, "dir/test.txt", "invalid"); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("file should not be deleted with a mismatched etag. %v", err)
+	}
+
+	if err := diskProvider.DeleteIfMatch(ctx, "dir/test.txt", etag); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _, err = diskProvider.GetWithETag(ctx, "dir/test.txt")
+	if err != nil || data != nil {
+		t.Fatalf("deleted file should return nil. data: %s, err: %v", data, err)
+	}
+
+	if _, err := diskProvider.SaveIfMatch(ctx, "dir/test.txt", []byte("v3"), etag); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("missing file should not be saved. %v", err)
+	}
+}
+
+func TestDiskConditionalConcurrentIncrement(t *testing.T) {
+	ctx := context.Background()
+	diskProvider := NewDisk(t.TempDir())
+
+	if _, err := diskProvider.SaveIfAbsent(ctx, "counter", []byte("0")); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				data, etag, err := diskProvider.GetWithETag(ctx, "counter")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				n, err := strconv.Atoi(string(data))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				_, err = diskProvider.SaveIfMatch(ctx, "counter", []byte(strconv.Itoa(n+1)), etag)
+				if errors.Is(err, ErrPreconditionFailed) {
+					continue
+				}
+				if err != nil {
+					t.Error(err)
+				}
+
+				return
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := diskProvider.Get(ctx, "counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != strconv.Itoa(workers) {
+		t.Fatalf("increments were lost. %s", data)
+	}
+}
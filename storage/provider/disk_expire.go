@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hatappi/go-kit/log"
+)
+
+// expiresDir is the directory under the root directory where Disk records expiry times of files saved with a TTL.
+const expiresDir = ".expires"
+
+// SetClock sets the function returning the current time, which decides when files saved with a TTL expire.
+// It defaults to time.Now.
+func (d *Disk) SetClock(clock func() time.Time) {
+	d.clock = clock
+}
+
+// StartJanitor starts a goroutine that deletes expired files every interval until ctx is canceled.
+// Deleted files are reported through the logger in ctx.
+func (d *Disk) StartJanitor(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := d.SweepExpired(ctx); err != nil {
+					log.FromContext(ctx).Error(err, "failed to sweep expired files")
+				}
+			}
+		}
+	}()
+}
+
+// SweepExpired deletes files whose TTL has passed and returns their paths.
+func (d *Disk) SweepExpired(ctx context.Context) ([]string, error) {
+	logger := log.FromContext(ctx)
+	root := path.Join(d.rootDir, expiresDir)
+
+	var deleted []string
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		expiresAt, err := readExpiry(p)
+		if err != nil {
+			return err
+		}
+
+		if d.now().Before(expiresAt) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		filePath := filepath.ToSlash(rel)
+
+		if err := d.Delete(ctx, filePath); err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+
+			// the file was removed outside of Disk, so only the expiry record is left.
+			if err := d.writeExpiry(filePath, nil); err != nil {
+				return err
+			}
+		}
+
+		logger.Info("deleted expired file", "path", filePath, "expiresAt", expiresAt)
+		deleted = append(deleted, filePath)
+
+		return nil
+	})
+	if err != nil {
+		return deleted, err
+	}
+
+	return deleted, nil
+}
+
+func (d *Disk) writeExpiry(filePath string, ttl *time.Duration) error {
+	p := d.expiryPath(filePath)
+
+	if ttl == nil {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	expiresAt := d.now().Add(*ttl).UTC().Format(time.RFC3339Nano)
+
+	return ioutil.WriteFile(p, []byte(expiresAt), 0644)
+}
+
+func (d *Disk) expiryPath(filePath string) string {
+	return path.Join(d.rootDir, expiresDir, filePath)
+}
+
+func (d *Disk) now() time.Time {
+	if d.clock == nil {
+		return time.Now()
+	}
+
+	return d.clock()
+}
+
+func readExpiry(p string) (time.Time, error) {
+	raw, err := ioutil.ReadFile(p)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339Nano, strings.TrimSpace(string(raw)))
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+func TestDiskSweepExpired(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	current := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	diskProvider := &Disk{
+		rootDir: dir,
+		clock:   func() time.Time { return current },
+	}
+
+	ctx := context.Background()
+	if _, err := diskProvider.Save(ctx, "tmp/short.txt", []byte("test"), option.SaveOptionWithTTL(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := diskProvider.Save(ctx, "tmp/long.txt", []byte("test"), option.SaveOptionWithTTL(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := diskProvider.Save(ctx, "keep.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	current = current.Add(2 * time.Hour)
+
+	deleted, err := diskProvider.SweepExpired(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]string{"tmp/short.txt"}, deleted); d != "" {
+		t.Fatalf("unexpected deleted files. %s", d)
+	}
+
+	for _, p := range []string{"tmp/long.txt", "keep.txt"} {
+		if _, err := os.Stat(path.Join(dir, p)); err != nil {
+			t.Fatalf("%s should not be deleted. %v", p, err)
+		}
+	}
+
+	if _, err := os.Stat(path.Join(dir, "tmp/short.txt")); !os.IsNotExist(err) {
+		t.Fatal("expired file was not deleted")
+	}
+
+	// saving again without a TTL makes the file permanent
+	if _, err := diskProvider.Save(ctx, "tmp/long.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	current = current.Add(48 * time.Hour)
+
+	deleted, err = diskProvider.SweepExpired(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 0 {
+		t.Fatalf("unexpected deleted files. %v", deleted)
+	}
+}
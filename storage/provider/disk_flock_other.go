@@ -0,0 +1,17 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func flock(f *os.File, exclusive bool) error {
+	return fmt.Errorf("file locking on %s: %w", runtime.GOOS, ErrNotSupported)
+}
+
+func funlock(f *os.File) error {
+	return fmt.Errorf("file locking on %s: %w", runtime.GOOS, ErrNotSupported)
+}
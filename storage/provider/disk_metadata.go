@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// metadataDir is the directory under the root directory where Disk keeps metadata given by option.SaveOption.
+const metadataDir = ".metadata"
+
+type diskMetadata struct {
+	ContentType        string `json:"contentType,omitempty"`
+	ContentDisposition string `json:"contentDisposition,omitempty"`
+}
+
+// SetContentTypeDetector makes Save detect the content type by d when it is not set by option.SaveOptionWithContentType.
+func (d *Disk) SetContentTypeDetector(detector *contenttype.Detector) {
+	d.contentTypeDetector = detector
+}
+
+// writeSidecars records the expiry and the metadata of the file given by saveOpt.
+func (d *Disk) writeSidecars(filePath string, saveOpt *option.SaveOption, ct *string) error {
+	if err := d.writeExpiry(filePath, saveOpt.TTL); err != nil {
+		return err
+	}
+
+	var md diskMetadata
+	if ct != nil {
+		md.ContentType = *ct
+	}
+	if saveOpt.ContentDisposition != nil {
+		md.ContentDisposition = *saveOpt.ContentDisposition
+	}
+
+	return d.writeMetadata(filePath, md)
+}
+
+// writeMetadata saves md for the file, or removes the metadata of the file when md is empty.
+func (d *Disk) writeMetadata(filePath string, md diskMetadata) error {
+	p := d.metadataPath(filePath)
+
+	if md == (diskMetadata{}) {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	raw, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p, raw, 0644)
+}
+
+func (d *Disk) readMetadata(filePath string) (diskMetadata, error) {
+	var md diskMetadata
+
+	raw, err := ioutil.ReadFile(d.metadataPath(filePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return md, nil
+		}
+
+		return md, err
+	}
+
+	if err := json.Unmarshal(raw, &md); err != nil {
+		return md, err
+	}
+
+	return md, nil
+}
+
+func (d *Disk) metadataPath(filePath string) string {
+	return path.Join(d.rootDir, metadataDir, filePath)
+}
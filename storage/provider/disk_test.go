@@ -2,12 +2,17 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/option"
 )
 
 func TestDiskSave(t *testing.T) {
@@ -118,3 +123,299 @@ func TestDiskPing(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestDiskGetRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(path.Join(dir, "test.txt"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	diskProvider := &Disk{
+		rootDir: dir,
+	}
+
+	testCases := []struct {
+		name     string
+		filePath string
+		offset   int64
+		length   int64
+
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name:     "read middle of file",
+			filePath: "test.txt",
+			offset:   2,
+			length:   3,
+			want:     []byte("234"),
+		},
+		{
+			name:     "read across end of file",
+			filePath: "test.txt",
+			offset:   8,
+			length:   5,
+			want:     []byte("89"),
+		},
+		{
+			name:     "file does not exist",
+			filePath: "missing.txt",
+			offset:   0,
+			length:   1,
+			want:     nil,
+		},
+		{
+			name:     "invalid length",
+			filePath: "test.txt",
+			offset:   0,
+			length:   0,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			actual, err := diskProvider.GetRange(ctx, tc.filePath, tc.offset, tc.length)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err: %v", err)
+			}
+
+			if d := cmp.Diff(tc.want, actual); d != "" {
+				t.Fatalf("unexpected contents. %s", d)
+			}
+		})
+	}
+}
+
+func TestDiskList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	diskProvider := &Disk{
+		rootDir: dir,
+	}
+
+	ctx := context.Background()
+	for _, p := range []string{"a/1.txt", "a/2.txt", "ab.txt", "b/1.txt"} {
+		if _, err := diskProvider.Save(ctx, p, []byte("test")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := diskProvider.SaveVersion(ctx, "a/3.txt", []byte("test"), option.SaveOptionWithTTL(time.Hour), option.SaveOptionWithContentType("text/plain")); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		prefix string
+
+		want []string
+	}{
+		{
+			name:   "all files",
+			prefix: "",
+			want:   []string{"a/1.txt", "a/2.txt", "a/3.txt", "ab.txt", "b/1.txt"},
+		},
+		{
+			name:   "directory prefix",
+			prefix: "a/",
+			want:   []string{"a/1.txt", "a/2.txt", "a/3.txt"},
+		},
+		{
+			name:   "partial name prefix",
+			prefix: "a",
+			want:   []string{"a/1.txt", "a/2.txt", "a/3.txt", "ab.txt"},
+		},
+		{
+			name:   "no match",
+			prefix: "c/",
+			want:   nil,
+		},
+		{
+			name:   "reserved directory",
+			prefix: ".versions/",
+			want:   nil,
+		},
+		{
+			name:   "reserved directory name prefix",
+			prefix: ".meta",
+			want:   nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			infos, err := diskProvider.List(ctx, tc.prefix)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var actual []string
+			for _, info := range infos {
+				actual = append(actual, info.Key)
+			}
+
+			if d := cmp.Diff(tc.want, actual); d != "" {
+				t.Fatalf("unexpected files. %s", d)
+			}
+		})
+	}
+}
+
+func TestDiskReservedKey(t *testing.T) {
+	diskProvider := NewDisk(t.TempDir())
+
+	ctx := context.Background()
+
+	if _, err := diskProvider.SaveVersion(ctx, "test.txt", []byte("test"), option.SaveOptionWithTTL(time.Hour), option.SaveOptionWithContentType("text/plain")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{".versions", ".versions/test.txt", ".expires/test.txt", ".metadata/test.txt", "a/../.metadata/test.txt"} {
+		key := key
+
+		t.Run(key, func(t *testing.T) {
+			if _, err := diskProvider.Save(ctx, key, []byte("overwritten")); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("Save should fail. %v", err)
+			}
+			if _, err := diskProvider.Get(ctx, key); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("Get should fail. %v", err)
+			}
+			if _, err := diskProvider.GetRange(ctx, key, 0, 1); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("GetRange should fail. %v", err)
+			}
+			if _, err := diskProvider.Stat(ctx, key); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("Stat should fail. %v", err)
+			}
+			if err := diskProvider.Delete(ctx, key); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("Delete should fail. %v", err)
+			}
+			if _, err := diskProvider.Compose(ctx, "composed.txt", []string{key}); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("Compose should fail. %v", err)
+			}
+			if _, _, err := diskProvider.GetWithETag(ctx, key); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("GetWithETag should fail. %v", err)
+			}
+			if _, err := diskProvider.SaveIfAbsent(ctx, key, []byte("overwritten")); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("SaveIfAbsent should fail. %v", err)
+			}
+			if _, err := diskProvider.SaveVersion(ctx, key, []byte("overwritten")); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("SaveVersion should fail. %v", err)
+			}
+			if _, err := diskProvider.ListVersions(ctx, key); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("ListVersions should fail. %v", err)
+			}
+		})
+	}
+
+	info, err := diskProvider.Stat(ctx, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || info.ContentType != "text/plain" {
+		t.Errorf("bookkeeping of the file should be kept. %+v", info)
+	}
+
+	versions, err := diskProvider.ListVersions(ctx, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Errorf("versions of the file should be kept. %+v", versions)
+	}
+}
+
+func TestDiskSaveWithContentTypeDetector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	diskProvider := &Disk{
+		rootDir: dir,
+	}
+	diskProvider.SetContentTypeDetector(contenttype.NewDetector(nil))
+
+	ctx := context.Background()
+	if _, err := diskProvider.Save(ctx, "index.html", []byte("<html></html>")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := diskProvider.Save(ctx, "data", []byte("test"), option.SaveOptionWithContentType("application/json")); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := diskProvider.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := map[string]string{}
+	for _, info := range infos {
+		actual[info.Key] = info.ContentType
+	}
+
+	expected := map[string]string{
+		"data":       "application/json",
+		"index.html": "text/html; charset=utf-8",
+	}
+	if d := cmp.Diff(expected, actual); d != "" {
+		t.Fatalf("unexpected content types. %s", d)
+	}
+
+	if err := diskProvider.Delete(ctx, "data"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(diskProvider.metadataPath("data")); !os.IsNotExist(err) {
+		t.Fatal("metadata was not deleted")
+	}
+}
+
+func TestDiskStat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	diskProvider := &Disk{
+		rootDir: dir,
+	}
+
+	ctx := context.Background()
+	if _, err := diskProvider.Save(ctx, "test.txt", []byte("test"), option.SaveOptionWithContentDisposition("attachment")); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := diskProvider.Stat(ctx, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info == nil || info.Key != "test.txt" || info.Size != 4 || info.ContentDisposition != "attachment" {
+		t.Fatalf("unexpected info. %+v", info)
+	}
+
+	info, err = diskProvider.Stat(ctx, "missing.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info != nil {
+		t.Fatalf("missing file should not have info. %+v", info)
+	}
+}
@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// versionsDir is the directory under the root directory where Disk keeps version snapshots.
+const versionsDir = ".versions"
+
+// SaveVersion saves data like Save and keeps a snapshot of it as a new version.
+// Only data saved by SaveVersion is versioned; Save and Delete leave the snapshots untouched.
+func (d *Disk) SaveVersion(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	if err := d.checkKey(filePath); err != nil {
+		return "", err
+	}
+
+	dir := d.versionDir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	versionID := fmt.Sprintf("%020d", time.Now().UnixNano())
+	if err := ioutil.WriteFile(filepath.Join(dir, versionID), data, 0644); err != nil {
+		return "", err
+	}
+
+	if _, err := d.Save(ctx, filePath, data, opts...); err != nil {
+		return "", err
+	}
+
+	return versionID, nil
+}
+
+// GetVersion returns the data of the specified version.
+// It returns nil when the version does not exist.
+func (d *Disk) GetVersion(ctx context.Context, filePath string, versionID string) ([]byte, error) {
+	if err := d.checkKey(filePath); err != nil {
+		return nil, err
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(d.versionDir(filePath), filepath.Base(versionID)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return raw, nil
+}
+
+// DeleteVersion deletes the snapshot of the specified version.
+// When the latest version is deleted, the file is restored from the previous version, or removed if none remains.
+func (d *Disk) DeleteVersion(ctx context.Context, filePath string, versionID string) error {
+	versions, err := d.ListVersions(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(d.versionDir(filePath), filepath.Base(versionID))); err != nil {
+		return err
+	}
+
+	if len(versions) == 0 || versions[0].VersionID != versionID {
+		return nil
+	}
+
+	if len(versions) == 1 {
+		if err := os.Remove(d.fileFullPath(filePath)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	data, err := d.GetVersion(ctx, filePath, versions[1].VersionID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.Save(ctx, filePath, data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListVersions returns versions of the file, newest first.
+func (d *Disk) ListVersions(ctx context.Context, filePath string) ([]object.Version, error) {
+	if err := d.checkKey(filePath); err != nil {
+		return nil, err
+	}
+
+	entries, err := ioutil.ReadDir(d.versionDir(filePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	versions := make([]object.Version, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		versions = append(versions, object.Version{
+			VersionID:    e.Name(),
+			Size:         e.Size(),
+			LastModified: e.ModTime(),
+		})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].VersionID > versions[j].VersionID
+	})
+
+	if len(versions) > 0 {
+		versions[0].IsLatest = true
+	}
+
+	return versions, nil
+}
+
+func (d *Disk) versionDir(filePath string) string {
+	return path.Join(d.rootDir, versionsDir, filePath)
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiskVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	diskProvider := &Disk{
+		rootDir: dir,
+	}
+
+	ctx := context.Background()
+
+	v1, err := diskProvider.SaveVersion(ctx, "test.txt", []byte("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2, err := diskProvider.SaveVersion(ctx, "test.txt", []byte("v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := diskProvider.ListVersions(ctx, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 2 || versions[0].VersionID != v2 || !versions[0].IsLatest || versions[1].VersionID != v1 {
+		t.Fatalf("unexpected versions. %+v", versions)
+	}
+
+	actual, err := diskProvider.GetVersion(ctx, "test.txt", v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]byte("v1"), actual); d != "" {
+		t.Fatalf("unexpected contents. %s", d)
+	}
+
+	if err := diskProvider.DeleteVersion(ctx, "test.txt", v2); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err = ioutil.ReadFile(path.Join(dir, "test.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]byte("v1"), actual); d != "" {
+		t.Fatalf("file was not restored to the previous version. %s", d)
+	}
+
+	if err := diskProvider.DeleteVersion(ctx, "test.txt", v1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path.Join(dir, "test.txt")); !os.IsNotExist(err) {
+		t.Fatal("file was not deleted with its last version")
+	}
+
+	actual, err = diskProvider.GetVersion(ctx, "test.txt", v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual != nil {
+		t.Fatalf("deleted version still exists. %s", actual)
+	}
+}
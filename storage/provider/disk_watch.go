@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+// Watch notifies changes of files whose path starts with prefix until ctx is canceled.
+// A file written by Save emits a created event followed by an updated event once the data is written.
+// Events may be duplicated for files created together with their directory.
+func (d *Disk) Watch(ctx context.Context, prefix string) (<-chan object.Event, error) {
+	dir := d.prefixDir(prefix)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.addWatchDirs(watcher, dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	ch := make(chan object.Event)
+
+	go func() {
+		defer close(ch)
+		defer watcher.Close()
+
+		logger := log.FromContext(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				logger.Error(err, "failed to watch files", "prefix", prefix)
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				for _, event := range d.watchEvents(watcher, e) {
+					if !strings.HasPrefix(event.Info.Key, prefix) {
+						continue
+					}
+
+					select {
+					case <-ctx.Done():
+						return
+					case ch <- event:
+					}
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (d *Disk) watchEvents(watcher *fsnotify.Watcher, e fsnotify.Event) []object.Event {
+	key, ok := d.fileKey(e.Name)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case e.Op&fsnotify.Create != 0:
+		fi, err := os.Stat(e.Name)
+		if err != nil {
+			return nil
+		}
+
+		if !fi.IsDir() {
+			return []object.Event{{Type: object.EventTypeCreated, Info: fileInfo(key, fi)}}
+		}
+
+		if err := d.addWatchDirs(watcher, e.Name); err != nil {
+			return nil
+		}
+
+		// files may be created under the new directory before it is watched
+		var events []object.Event
+		_ = filepath.Walk(e.Name, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+
+			if key, ok := d.fileKey(p); ok {
+				events = append(events, object.Event{Type: object.EventTypeCreated, Info: fileInfo(key, info)})
+			}
+
+			return nil
+		})
+
+		return events
+	case e.Op&fsnotify.Write != 0:
+		fi, err := os.Stat(e.Name)
+		if err != nil || fi.IsDir() {
+			return nil
+		}
+
+		return []object.Event{{Type: object.EventTypeUpdated, Info: fileInfo(key, fi)}}
+	case e.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		return []object.Event{{Type: object.EventTypeDeleted, Info: object.Info{Key: key}}}
+	default:
+		return nil
+	}
+}
+
+func (d *Disk) addWatchDirs(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		if d.isInternalPath(p) {
+			return filepath.SkipDir
+		}
+
+		return watcher.Add(p)
+	})
+}
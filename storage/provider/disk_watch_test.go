@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+func TestDiskWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	diskProvider := &Disk{
+		rootDir: dir,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := diskProvider.Watch(ctx, "uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := diskProvider.Save(ctx, "other.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := diskProvider.Save(ctx, "uploads/test.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	event := receiveEvent(t, ch)
+	if event.Type != object.EventTypeCreated || event.Info.Key != "uploads/test.txt" {
+		t.Fatalf("unexpected event. %+v", event)
+	}
+
+	if err := diskProvider.Delete(ctx, "uploads/test.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		event := receiveEvent(t, ch)
+		if event.Type == object.EventTypeUpdated {
+			continue
+		}
+
+		if event.Type != object.EventTypeDeleted || event.Info.Key != "uploads/test.txt" {
+			t.Fatalf("unexpected event. %+v", event)
+		}
+
+		break
+	}
+
+	cancel()
+
+	for range ch {
+	}
+}
+
+func receiveEvent(t *testing.T, ch <-chan object.Event) object.Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-ch:
+		if !ok {
+			t.Fatal("channel was closed")
+		}
+
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+
+	return object.Event{}
+}
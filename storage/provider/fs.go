@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// FS is a read-only provider backed by an fs.FS such as embed.FS.
+// Save and Delete fail with an *fs.PathError wrapping fs.ErrPermission.
+type FS struct {
+	fsys fs.FS
+}
+
+func NewFS(fsys fs.FS) *FS {
+	return &FS{
+		fsys: fsys,
+	}
+}
+
+func (f *FS) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	return "", &fs.PathError{Op: "save", Path: filePath, Err: fs.ErrPermission}
+}
+
+func (f *FS) Get(ctx context.Context, filePath string) ([]byte, error) {
+	data, err := fs.ReadFile(f.fsys, filePath)
+	if err != nil {
+		if f.notFile(filePath, err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetRange returns length bytes of the file starting at offset.
+// The returned slice is shorter than length when the file ends before the range does.
+func (f *FS) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if offset < 0 || length <= 0 {
+		return nil, fmt.Errorf("invalid range. offset: %d, length: %d", offset, length)
+	}
+
+	fi, err := fs.Stat(f.fsys, filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return nil, nil
+	}
+
+	file, err := f.fsys.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if s, ok := file.(io.Seeker); ok {
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	} else if _, err := io.CopyN(io.Discard, file, offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, length))
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Stat returns nil when the file does not exist or is a directory.
+func (f *FS) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	fi, err := fs.Stat(f.fsys, filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return nil, nil
+	}
+
+	info := fsObjectInfo(filePath, fi)
+
+	return &info, nil
+}
+
+// List returns files whose path starts with prefix, sorted by path.
+func (f *FS) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	root := "."
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		root = prefix[:i]
+	}
+
+	var infos []object.Info
+	err := fs.WalkDir(f.fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.IsDir() || !strings.HasPrefix(p, prefix) {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		infos = append(infos, fsObjectInfo(p, fi))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+func (f *FS) Delete(ctx context.Context, filePath string) error {
+	return &fs.PathError{Op: "delete", Path: filePath, Err: fs.ErrPermission}
+}
+
+// Ping checks the root directory can be read.
+func (f *FS) Ping(ctx context.Context) error {
+	_, err := fs.ReadDir(f.fsys, ".")
+
+	return err
+}
+
+// notFile reports whether err was caused by filePath not existing or being a directory.
+func (f *FS) notFile(filePath string, err error) bool {
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+
+	fi, statErr := fs.Stat(f.fsys, filePath)
+
+	return statErr == nil && fi.IsDir()
+}
+
+func fsObjectInfo(key string, fi fs.FileInfo) object.Info {
+	return object.Info{
+		Key:          path.Clean(key),
+		Size:         fi.Size(),
+		LastModified: fi.ModTime(),
+	}
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+func TestFS(t *testing.T) {
+	modTime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	fsProvider := NewFS(fstest.MapFS{
+		"a.txt":         {Data: []byte("hello"), ModTime: modTime},
+		"dir/b.txt":     {Data: []byte("world"), ModTime: modTime},
+		"dir/sub/c.txt": {Data: []byte("!"), ModTime: modTime},
+		"dirty.txt":     {Data: []byte("x"), ModTime: modTime},
+	})
+
+	ctx := context.Background()
+
+	data, err := fsProvider.Get(ctx, "dir/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "world" {
+		t.Errorf("unexpected contents. %s", data)
+	}
+
+	data, err = fsProvider.Get(ctx, "missing.txt")
+	if err != nil || data != nil {
+		t.Errorf("missing file should return nil. data: %v, err: %v", data, err)
+	}
+
+	data, err = fsProvider.Get(ctx, "dir")
+	if err != nil || data != nil {
+		t.Errorf("directory should return nil. data: %v, err: %v", data, err)
+	}
+
+	data, err = fsProvider.GetRange(ctx, "a.txt", 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "ell" {
+		t.Errorf("unexpected range. %s", data)
+	}
+
+	info, err := fsProvider.Stat(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(&object.Info{Key: "a.txt", Size: 5, LastModified: modTime}, info); d != "" {
+		t.Errorf("info was a mismatch. %s", d)
+	}
+
+	info, err = fsProvider.Stat(ctx, "dir")
+	if err != nil || info != nil {
+		t.Errorf("directory should not be an object. info: %v, err: %v", info, err)
+	}
+
+	testCases := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "", want: []string{"a.txt", "dir/b.txt", "dir/sub/c.txt", "dirty.txt"}},
+		{prefix: "dir", want: []string{"dir/b.txt", "dir/sub/c.txt", "dirty.txt"}},
+		{prefix: "dir/", want: []string{"dir/b.txt", "dir/sub/c.txt"}},
+		{prefix: "dir/sub/c", want: []string{"dir/sub/c.txt"}},
+		{prefix: "missing/", want: nil},
+	}
+
+	for _, tc := range testCases {
+		infos, err := fsProvider.List(ctx, tc.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []string
+		for _, info := range infos {
+			keys = append(keys, info.Key)
+		}
+
+		if d := cmp.Diff(tc.want, keys); d != "" {
+			t.Errorf("keys with prefix %q were a mismatch. %s", tc.prefix, d)
+		}
+	}
+
+	if _, err := fsProvider.Save(ctx, "a.txt", []byte("x")); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("save should not be permitted. %v", err)
+	}
+
+	if err := fsProvider.Delete(ctx, "a.txt"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("delete should not be permitted. %v", err)
+	}
+
+	if err := fsProvider.Ping(ctx); err != nil {
+		t.Errorf("ping failed. %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// Memory keeps files in memory. It is meant for tests and ephemeral data.
+type Memory struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+
+	contentTypeDetector *contenttype.Detector
+}
+
+type memoryFile struct {
+	data []byte
+	info object.Info
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		files: map[string]memoryFile{},
+	}
+}
+
+// SetContentTypeDetector makes Save detect the content type by d when it is not set by option.SaveOptionWithContentType.
+func (m *Memory) SetContentTypeDetector(d *contenttype.Detector) {
+	m.contentTypeDetector = d
+}
+
+func (m *Memory) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	f := m.newFile(filePath, data, opts...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[filePath] = f
+
+	return "mem://" + filePath, nil
+}
+
+func (m *Memory) newFile(filePath string, data []byte, opts ...option.SaveOptionFunc) memoryFile {
+	var saveOpt option.SaveOption
+	for _, opt := range opts {
+		opt(&saveOpt)
+	}
+
+	b := make([]byte, len(data))
+	copy(b, data)
+
+	info := object.Info{
+		Key:          filePath,
+		Size:         int64(len(b)),
+		LastModified: time.Now(),
+	}
+	if ct := contentType(&saveOpt, m.contentTypeDetector, filePath, data); ct != nil {
+		info.ContentType = *ct
+	}
+	if saveOpt.ContentDisposition != nil {
+		info.ContentDisposition = *saveOpt.ContentDisposition
+	}
+
+	return memoryFile{
+		data: b,
+		info: info,
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, filePath string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.files[filePath]
+	if !ok {
+		return nil, nil
+	}
+
+	b := make([]byte, len(f.data))
+	copy(b, f.data)
+
+	return b, nil
+}
+
+// GetRange returns length bytes of the file starting at offset.
+// The returned slice is shorter than length when the file ends before the range does.
+func (m *Memory) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if offset < 0 || length <= 0 {
+		return nil, fmt.Errorf("invalid range. offset: %d, length: %d", offset, length)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.files[filePath]
+	if !ok {
+		return nil, nil
+	}
+
+	if offset >= int64(len(f.data)) {
+		return []byte{}, nil
+	}
+
+	end := offset + length
+	if end > int64(len(f.data)) {
+		end = int64(len(f.data))
+	}
+
+	b := make([]byte, end-offset)
+	copy(b, f.data[offset:end])
+
+	return b, nil
+}
+
+// Stat returns the information of the file. It returns nil when the file does not exist.
+func (m *Memory) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.files[filePath]
+	if !ok {
+		return nil, nil
+	}
+
+	info := f.info
+
+	return &info, nil
+}
+
+// List returns files whose path starts with prefix, sorted by path.
+func (m *Memory) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var infos []object.Info
+	for key, f := range m.files {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, f.info)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+
+	return infos, nil
+}
+
+// Compose saves the concatenation of the sources to filePath.
+func (m *Memory) Compose(ctx context.Context, filePath string, sources []string, opts ...option.SaveOptionFunc) (string, error) {
+	var data []byte
+	for _, src := range sources {
+		b, err := m.Get(ctx, src)
+		if err != nil {
+			return "", err
+		}
+
+		if b == nil {
+			return "", fmt.Errorf("%s does not exist", src)
+		}
+
+		data = append(data, b...)
+	}
+
+	return m.Save(ctx, filePath, data, opts...)
+}
+
+func (m *Memory) Delete(ctx context.Context, filePath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.files, filePath)
+
+	return nil
+}
+
+func (m *Memory) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// GetWithETag returns the data and the ETag of the file. The data is nil when the file does not exist.
+func (m *Memory) GetWithETag(ctx context.Context, filePath string) ([]byte, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.files[filePath]
+	if !ok {
+		return nil, "", nil
+	}
+
+	b := make([]byte, len(f.data))
+	copy(b, f.data)
+
+	return b, contentETag(b), nil
+}
+
+// SaveIfAbsent saves the file only when it does not exist. It returns ErrPreconditionFailed otherwise.
+func (m *Memory) SaveIfAbsent(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	f := m.newFile(filePath, data, opts...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.files[filePath]; ok {
+		return "", ErrPreconditionFailed
+	}
+
+	m.files[filePath] = f
+
+	return contentETag(f.data), nil
+}
+
+// SaveIfMatch replaces the file only when its ETag equals etag. It returns ErrPreconditionFailed otherwise.
+func (m *Memory) SaveIfMatch(ctx context.Context, filePath string, data []byte, etag string, opts ...option.SaveOptionFunc) (string, error) {
+	f := m.newFile(filePath, data, opts...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.files[filePath]
+	if !ok || contentETag(current.data) != etag {
+		return "", ErrPreconditionFailed
+	}
+
+	m.files[filePath] = f
+
+	return contentETag(f.data), nil
+}
+
+// DeleteIfMatch deletes the file only when its ETag equals etag. It returns ErrPreconditionFailed otherwise.
+func (m *Memory) DeleteIfMatch(ctx context.Context, filePath string, etag string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.files[filePath]
+	if !ok || contentETag(current.data) != etag {
+		return ErrPreconditionFailed
+	}
+
+	delete(m.files, filePath)
+
+	return nil
+}
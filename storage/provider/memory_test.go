@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMemory(t *testing.T) {
+	memoryProvider := NewMemory()
+
+	ctx := context.Background()
+	for _, p := range []string{"a/1.txt", "a/2.txt", "b/1.txt"} {
+		if _, err := memoryProvider.Save(ctx, p, []byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	actual, err := memoryProvider.Get(ctx, "a/1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]byte("0123456789"), actual); d != "" {
+		t.Fatalf("unexpected contents. %s", d)
+	}
+
+	actual, err = memoryProvider.GetRange(ctx, "a/1.txt", 8, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]byte("89"), actual); d != "" {
+		t.Fatalf("unexpected contents. %s", d)
+	}
+
+	infos, err := memoryProvider.List(ctx, "a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 2 || infos[0].Key != "a/1.txt" || infos[1].Key != "a/2.txt" {
+		t.Fatalf("unexpected files. %+v", infos)
+	}
+
+	if err := memoryProvider.Delete(ctx, "a/1.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err = memoryProvider.Get(ctx, "a/1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual != nil {
+		t.Fatal("file was not deleted")
+	}
+
+	if err := memoryProvider.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// ErrNotSupported is returned when an operation is not available, such as file locking on some platforms.
+var ErrNotSupported = errors.New("operation not supported")
+
+// ErrPreconditionFailed is returned by the conditional operations when the object exists or was changed.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// contentType returns the content type set by the option, or detects it by d when d is not nil.
+func contentType(saveOpt *option.SaveOption, d *contenttype.Detector, name string, data []byte) *string {
+	if saveOpt.ContentType != nil || d == nil {
+		return saveOpt.ContentType
+	}
+
+	ct := d.Detect(name, data)
+
+	return &ct
+}
+
+// contentETag returns the ETag of the providers that have no native one.
+func contentETag(data []byte) string {
+	sum := md5.Sum(data)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -6,7 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
+	"net/url"
 	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -14,14 +20,37 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/object"
 	"github.com/hatappi/go-kit/storage/option"
 )
 
+const (
+	// errCodeInvalidRange is returned by S3 when the requested range starts beyond the end of the object.
+	errCodeInvalidRange = "InvalidRange"
+	// errCodeNoSuchVersion is returned by S3 when the requested version does not exist.
+	errCodeNoSuchVersion = "NoSuchVersion"
+	// errCodeNotFound is returned by HeadObject when the object does not exist, since HEAD responses have no body.
+	errCodeNotFound = "NotFound"
+)
+
+const (
+	// S3ExpiresAtTagKey is the object tag holding the RFC 3339 time at which an object saved with a TTL expires.
+	S3ExpiresAtTagKey = "expires-at"
+	// S3TTLDaysTagKey is the object tag holding the TTL in days, rounded up.
+	// A bucket lifecycle rule filtering on this tag, e.g. ttl-days=1 expiring after 1 day, deletes the objects.
+	S3TTLDaysTagKey = "ttl-days"
+)
+
 type S3 struct {
 	bucketName string
 	prefixPath string
 
 	s3Service s3iface.S3API
+
+	watchInterval       time.Duration
+	contentTypeDetector *contenttype.Detector
+	clock               func() time.Time
 }
 
 func NewS3(bucketName string, prefixPath string, region string) (*S3, error) {
@@ -31,46 +60,77 @@ func NewS3(bucketName string, prefixPath string, region string) (*S3, error) {
 	}
 
 	return &S3{
-		s3Service:  s3.New(sess, aws.NewConfig().WithRegion(region)),
-		bucketName: bucketName,
-		prefixPath: prefixPath,
+		s3Service:     s3.New(sess, aws.NewConfig().WithRegion(region)),
+		bucketName:    bucketName,
+		prefixPath:    prefixPath,
+		watchInterval: defaultS3WatchInterval,
 	}, nil
 }
 
+// SetContentTypeDetector makes Save detect the content type by d when it is not set by option.SaveOptionWithContentType.
+func (s *S3) SetContentTypeDetector(d *contenttype.Detector) {
+	s.contentTypeDetector = d
+}
+
+// SetClock sets the function returning the current time, from which the expiry times of objects saved with a TTL are tagged.
+// It defaults to time.Now.
+func (s *S3) SetClock(clock func() time.Time) {
+	s.clock = clock
+}
+
 func (s *S3) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
-	var saveOpt option.SaveOption
-	for _, opt := range opts {
-		opt(&saveOpt)
+	key := s.objectKey(filePath)
+
+	if _, err := s.putObject(ctx, key, data, opts...); err != nil {
+		return "", err
 	}
+	uri := fmt.Sprintf("s3://%s/%s", s.bucketName, key)
 
+	return uri, nil
+}
+
+func (s *S3) Get(ctx context.Context, filePath string) ([]byte, error) {
 	key := s.objectKey(filePath)
 
-	input := &s3.PutObjectInput{
-		Body:   bytes.NewReader(data),
+	input := &s3.GetObjectInput{
 		Bucket: aws.String(s.bucketName),
 		Key:    aws.String(key),
 	}
-	if saveOpt.ContentType != nil {
-		input.ContentType = saveOpt.ContentType
-	}
-	if saveOpt.ContentDisposition != nil {
-		input.SetContentDisposition(*saveOpt.ContentDisposition)
+
+	o, err := s.s3Service.GetObjectWithContext(ctx, input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case s3.ErrCodeNoSuchKey:
+				return nil, nil
+			}
+		}
+
+		return nil, err
 	}
+	defer o.Body.Close()
 
-	if _, err := s.s3Service.PutObjectWithContext(ctx, input); err != nil {
-		return "", err
+	resBody, err := ioutil.ReadAll(o.Body)
+	if err != nil {
+		return nil, err
 	}
-	uri := fmt.Sprintf("s3://%s/%s", s.bucketName, key)
 
-	return uri, nil
+	return resBody, nil
 }
 
-func (s *S3) Get(ctx context.Context, filePath string) ([]byte, error) {
+// GetRange returns length bytes of the object starting at offset by using the Range header.
+// The returned slice is shorter than length when the object ends before the range does.
+func (s *S3) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if offset < 0 || length <= 0 {
+		return nil, fmt.Errorf("invalid range. offset: %d, length: %d", offset, length)
+	}
+
 	key := s.objectKey(filePath)
 
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(s.bucketName),
 		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
 	}
 
 	o, err := s.s3Service.GetObjectWithContext(ctx, input)
@@ -79,6 +139,8 @@ func (s *S3) Get(ctx context.Context, filePath string) ([]byte, error) {
 			switch aerr.Code() {
 			case s3.ErrCodeNoSuchKey:
 				return nil, nil
+			case errCodeInvalidRange:
+				return []byte{}, nil
 			}
 		}
 
@@ -109,6 +171,173 @@ func (s *S3) Delete(ctx context.Context, filePath string) error {
 	return nil
 }
 
+// Stat returns the information of the object by HeadObject. It returns nil when the object does not exist.
+func (s *S3) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.objectKey(filePath)),
+	}
+
+	o, err := s.s3Service.HeadObjectWithContext(ctx, input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case s3.ErrCodeNoSuchKey, errCodeNotFound:
+				return nil, nil
+			}
+		}
+
+		return nil, err
+	}
+
+	return &object.Info{
+		Key:                filePath,
+		Size:               aws.Int64Value(o.ContentLength),
+		LastModified:       aws.TimeValue(o.LastModified),
+		ETag:               aws.StringValue(o.ETag),
+		ContentType:        aws.StringValue(o.ContentType),
+		ContentDisposition: aws.StringValue(o.ContentDisposition),
+	}, nil
+}
+
+// List returns objects whose key starts with prefix, sorted by key.
+func (s *S3) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	base := s.objectKey("")
+	if base != "" {
+		base += "/"
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(base + prefix),
+	}
+
+	var infos []object.Info
+	err := s.s3Service.ListObjectsV2PagesWithContext(ctx, input, func(o *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, c := range o.Contents {
+			infos = append(infos, object.Info{
+				Key:          strings.TrimPrefix(aws.StringValue(c.Key), base),
+				Size:         aws.Int64Value(c.Size),
+				LastModified: aws.TimeValue(c.LastModified),
+				ETag:         aws.StringValue(c.ETag),
+			})
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// SaveVersion saves data like Save and returns the version ID assigned by S3.
+// The bucket must have versioning enabled, otherwise an empty version ID is returned.
+func (s *S3) SaveVersion(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	o, err := s.putObject(ctx, s.objectKey(filePath), data, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(o.VersionId), nil
+}
+
+// GetVersion returns the data of the specified version.
+// It returns nil when the object or the version does not exist.
+func (s *S3) GetVersion(ctx context.Context, filePath string, versionID string) ([]byte, error) {
+	input := &s3.GetObjectInput{
+		Bucket:    aws.String(s.bucketName),
+		Key:       aws.String(s.objectKey(filePath)),
+		VersionId: aws.String(versionID),
+	}
+
+	o, err := s.s3Service.GetObjectWithContext(ctx, input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case s3.ErrCodeNoSuchKey, errCodeNoSuchVersion:
+				return nil, nil
+			}
+		}
+
+		return nil, err
+	}
+	defer o.Body.Close()
+
+	resBody, err := ioutil.ReadAll(o.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return resBody, nil
+}
+
+// DeleteVersion permanently deletes the specified version.
+func (s *S3) DeleteVersion(ctx context.Context, filePath string, versionID string) error {
+	input := &s3.DeleteObjectInput{
+		Bucket:    aws.String(s.bucketName),
+		Key:       aws.String(s.objectKey(filePath)),
+		VersionId: aws.String(versionID),
+	}
+
+	if _, err := s.s3Service.DeleteObjectWithContext(ctx, input); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListVersions returns versions and delete markers of the object, newest first.
+func (s *S3) ListVersions(ctx context.Context, filePath string) ([]object.Version, error) {
+	key := s.objectKey(filePath)
+
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(key),
+	}
+
+	var versions []object.Version
+	err := s.s3Service.ListObjectVersionsPagesWithContext(ctx, input, func(o *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		for _, v := range o.Versions {
+			if aws.StringValue(v.Key) != key {
+				continue
+			}
+
+			versions = append(versions, object.Version{
+				VersionID:    aws.StringValue(v.VersionId),
+				IsLatest:     aws.BoolValue(v.IsLatest),
+				Size:         aws.Int64Value(v.Size),
+				LastModified: aws.TimeValue(v.LastModified),
+			})
+		}
+
+		for _, m := range o.DeleteMarkers {
+			if aws.StringValue(m.Key) != key {
+				continue
+			}
+
+			versions = append(versions, object.Version{
+				VersionID:      aws.StringValue(m.VersionId),
+				IsLatest:       aws.BoolValue(m.IsLatest),
+				IsDeleteMarker: true,
+				LastModified:   aws.TimeValue(m.LastModified),
+			})
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].LastModified.After(versions[j].LastModified)
+	})
+
+	return versions, nil
+}
+
 func (s *S3) Ping(ctx context.Context) error {
 	filePath := "ping"
 
@@ -132,6 +361,52 @@ func (s *S3) Ping(ctx context.Context) error {
 	return nil
 }
 
+func (s *S3) putObject(ctx context.Context, key string, data []byte, opts ...option.SaveOptionFunc) (*s3.PutObjectOutput, error) {
+	return s.s3Service.PutObjectWithContext(ctx, s.putObjectInput(key, data, opts...))
+}
+
+func (s *S3) putObjectInput(key string, data []byte, opts ...option.SaveOptionFunc) *s3.PutObjectInput {
+	var saveOpt option.SaveOption
+	for _, opt := range opts {
+		opt(&saveOpt)
+	}
+
+	input := &s3.PutObjectInput{
+		Body:   bytes.NewReader(data),
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	}
+	if ct := contentType(&saveOpt, s.contentTypeDetector, key, data); ct != nil {
+		input.ContentType = ct
+	}
+	if saveOpt.ContentDisposition != nil {
+		input.SetContentDisposition(*saveOpt.ContentDisposition)
+	}
+	if saveOpt.TTL != nil {
+		input.SetTagging(s.expirationTagging(*saveOpt.TTL))
+	}
+
+	return input
+}
+
+func (s *S3) expirationTagging(ttl time.Duration) string {
+	days := int64(math.Ceil(ttl.Hours() / 24))
+
+	tags := url.Values{}
+	tags.Set(S3ExpiresAtTagKey, s.now().Add(ttl).UTC().Format(time.RFC3339))
+	tags.Set(S3TTLDaysTagKey, strconv.FormatInt(days, 10))
+
+	return tags.Encode()
+}
+
+func (s *S3) now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+
+	return s.clock()
+}
+
 func (s *S3) objectKey(filePath string) string {
 	return path.Join(s.prefixPath, filePath)
 }
@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// s3MinPartSize is the minimum size of the parts of a multipart upload except the last one.
+const s3MinPartSize = 5 << 20
+
+// Compose saves the concatenation of the sources to filePath by a multipart upload.
+// Sources smaller than the minimum part size are buffered until they fill a part.
+// The content type is not detected; set it by option.SaveOptionWithContentType if needed.
+func (s *S3) Compose(ctx context.Context, filePath string, sources []string, opts ...option.SaveOptionFunc) (string, error) {
+	var saveOpt option.SaveOption
+	for _, opt := range opts {
+		opt(&saveOpt)
+	}
+
+	key := s.objectKey(filePath)
+
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:             aws.String(s.bucketName),
+		Key:                aws.String(key),
+		ContentType:        saveOpt.ContentType,
+		ContentDisposition: saveOpt.ContentDisposition,
+	}
+	if saveOpt.TTL != nil {
+		input.SetTagging(s.expirationTagging(*saveOpt.TTL))
+	}
+
+	o, err := s.s3Service.CreateMultipartUploadWithContext(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.uploadParts(ctx, key, o.UploadId, sources); err != nil {
+		_, abortErr := s.s3Service.AbortMultipartUploadWithContext(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucketName),
+			Key:      aws.String(key),
+			UploadId: o.UploadId,
+		})
+		if abortErr != nil {
+			return "", fmt.Errorf("%w. failed to abort the multipart upload. %s", err, abortErr)
+		}
+
+		return "", err
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.bucketName, key), nil
+}
+
+func (s *S3) uploadParts(ctx context.Context, key string, uploadID *string, sources []string) error {
+	var (
+		parts []*s3.CompletedPart
+		buf   bytes.Buffer
+	)
+
+	flush := func() error {
+		partNumber := aws.Int64(int64(len(parts) + 1))
+
+		o, err := s.s3Service.UploadPartWithContext(ctx, &s3.UploadPartInput{
+			Body:       bytes.NewReader(buf.Bytes()),
+			Bucket:     aws.String(s.bucketName),
+			Key:        aws.String(key),
+			PartNumber: partNumber,
+			UploadId:   uploadID,
+		})
+		if err != nil {
+			return err
+		}
+
+		parts = append(parts, &s3.CompletedPart{
+			ETag:       o.ETag,
+			PartNumber: partNumber,
+		})
+		buf.Reset()
+
+		return nil
+	}
+
+	for _, src := range sources {
+		data, err := s.Get(ctx, src)
+		if err != nil {
+			return err
+		}
+
+		if data == nil {
+			return fmt.Errorf("%s does not exist", src)
+		}
+
+		buf.Write(data)
+
+		if buf.Len() >= s3MinPartSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// a multipart upload needs at least one part even if it is empty
+	if buf.Len() > 0 || len(parts) == 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.s3Service.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucketName),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+
+	return err
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+const (
+	// errCodePreconditionFailed is returned by S3 when the condition of a conditional write does not hold.
+	errCodePreconditionFailed = "PreconditionFailed"
+	// errCodeConditionalRequestConflict is returned by S3 when a concurrent conditional write to the same key is in progress.
+	errCodeConditionalRequestConflict = "ConditionalRequestConflict"
+)
+
+// GetWithETag returns the data and the ETag of the object. The data is nil when the object does not exist.
+func (s *S3) GetWithETag(ctx context.Context, filePath string) ([]byte, string, error) {
+	o, err := s.s3Service.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.objectKey(filePath)),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, "", nil
+		}
+
+		return nil, "", err
+	}
+	defer o.Body.Close()
+
+	data, err := io.ReadAll(o.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, aws.StringValue(o.ETag), nil
+}
+
+// SaveIfAbsent saves the object with If-None-Match: * so that it fails with ErrPreconditionFailed when the object exists.
+func (s *S3) SaveIfAbsent(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	return s.conditionalPut(ctx, filePath, data, withS3Header("If-None-Match", "*"), opts...)
+}
+
+// SaveIfMatch saves the object with If-Match so that it fails with ErrPreconditionFailed when the object was changed.
+func (s *S3) SaveIfMatch(ctx context.Context, filePath string, data []byte, etag string, opts ...option.SaveOptionFunc) (string, error) {
+	return s.conditionalPut(ctx, filePath, data, withS3Header("If-Match", etag), opts...)
+}
+
+// DeleteIfMatch deletes the object with If-Match so that it fails with ErrPreconditionFailed when the object was changed.
+func (s *S3) DeleteIfMatch(ctx context.Context, filePath string, etag string) error {
+	_, err := s.s3Service.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.objectKey(filePath)),
+	}, withS3Header("If-Match", etag))
+
+	return conditionalError(err)
+}
+
+func (s *S3) conditionalPut(ctx context.Context, filePath string, data []byte, condition request.Option, opts ...option.SaveOptionFunc) (string, error) {
+	o, err := s.s3Service.PutObjectWithContext(ctx, s.putObjectInput(s.objectKey(filePath), data, opts...), condition)
+	if err != nil {
+		return "", conditionalError(err)
+	}
+
+	return aws.StringValue(o.ETag), nil
+}
+
+// withS3Header sets the header that aws-sdk-go does not expose as an input field.
+func withS3Header(key, value string) request.Option {
+	return func(r *request.Request) {
+		r.HTTPRequest.Header.Set(key, value)
+	}
+}
+
+func conditionalError(err error) error {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case errCodePreconditionFailed, errCodeConditionalRequestConflict, s3.ErrCodeNoSuchKey:
+			return ErrPreconditionFailed
+		}
+	}
+
+	return err
+}
@@ -3,16 +3,24 @@ package provider
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
 )
 
 type mockS3Client struct {
@@ -21,6 +29,15 @@ type mockS3Client struct {
 	mockPutObjectWithContext    func(aws.Context, *s3.PutObjectInput, ...request.Option) (*s3.PutObjectOutput, error)
 	mockGetObjectWithContext    func(aws.Context, *s3.GetObjectInput, ...request.Option) (*s3.GetObjectOutput, error)
 	mockDeleteObjectWithContext func(aws.Context, *s3.DeleteObjectInput, ...request.Option) (*s3.DeleteObjectOutput, error)
+
+	mockListObjectVersionsPagesWithContext func(aws.Context, *s3.ListObjectVersionsInput, func(*s3.ListObjectVersionsOutput, bool) bool, ...request.Option) error
+	mockListObjectsV2PagesWithContext      func(aws.Context, *s3.ListObjectsV2Input, func(*s3.ListObjectsV2Output, bool) bool, ...request.Option) error
+	mockHeadObjectWithContext              func(aws.Context, *s3.HeadObjectInput, ...request.Option) (*s3.HeadObjectOutput, error)
+
+	mockCreateMultipartUploadWithContext   func(aws.Context, *s3.CreateMultipartUploadInput, ...request.Option) (*s3.CreateMultipartUploadOutput, error)
+	mockUploadPartWithContext              func(aws.Context, *s3.UploadPartInput, ...request.Option) (*s3.UploadPartOutput, error)
+	mockCompleteMultipartUploadWithContext func(aws.Context, *s3.CompleteMultipartUploadInput, ...request.Option) (*s3.CompleteMultipartUploadOutput, error)
+	mockAbortMultipartUploadWithContext    func(aws.Context, *s3.AbortMultipartUploadInput, ...request.Option) (*s3.AbortMultipartUploadOutput, error)
 }
 
 func (m *mockS3Client) PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
@@ -35,6 +52,34 @@ func (m *mockS3Client) DeleteObjectWithContext(ctx aws.Context, input *s3.Delete
 	return m.mockDeleteObjectWithContext(ctx, input, opts...)
 }
 
+func (m *mockS3Client) ListObjectVersionsPagesWithContext(ctx aws.Context, input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool, opts ...request.Option) error {
+	return m.mockListObjectVersionsPagesWithContext(ctx, input, fn, opts...)
+}
+
+func (m *mockS3Client) ListObjectsV2PagesWithContext(ctx aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+	return m.mockListObjectsV2PagesWithContext(ctx, input, fn, opts...)
+}
+
+func (m *mockS3Client) HeadObjectWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+	return m.mockHeadObjectWithContext(ctx, input, opts...)
+}
+
+func (m *mockS3Client) CreateMultipartUploadWithContext(ctx aws.Context, input *s3.CreateMultipartUploadInput, opts ...request.Option) (*s3.CreateMultipartUploadOutput, error) {
+	return m.mockCreateMultipartUploadWithContext(ctx, input, opts...)
+}
+
+func (m *mockS3Client) UploadPartWithContext(ctx aws.Context, input *s3.UploadPartInput, opts ...request.Option) (*s3.UploadPartOutput, error) {
+	return m.mockUploadPartWithContext(ctx, input, opts...)
+}
+
+func (m *mockS3Client) CompleteMultipartUploadWithContext(ctx aws.Context, input *s3.CompleteMultipartUploadInput, opts ...request.Option) (*s3.CompleteMultipartUploadOutput, error) {
+	return m.mockCompleteMultipartUploadWithContext(ctx, input, opts...)
+}
+
+func (m *mockS3Client) AbortMultipartUploadWithContext(ctx aws.Context, input *s3.AbortMultipartUploadInput, opts ...request.Option) (*s3.AbortMultipartUploadOutput, error) {
+	return m.mockAbortMultipartUploadWithContext(ctx, input, opts...)
+}
+
 func TestS3Save(t *testing.T) {
 	type args struct {
 		filepath string
@@ -384,3 +429,741 @@ func TestS3Ping(t *testing.T) {
 		})
 	}
 }
+
+func TestS3GetRange(t *testing.T) {
+	type args struct {
+		filepath string
+		offset   int64
+		length   int64
+	}
+
+	testCases := []struct {
+		name                     string
+		args                     args
+		mockGetObjectWithContext func(aws.Context, *s3.GetObjectInput, ...request.Option) (*s3.GetObjectOutput, error)
+		wantBody                 []byte
+		wantErr                  bool
+	}{
+		{
+			name: "success",
+			args: args{
+				filepath: "foo",
+				offset:   2,
+				length:   3,
+			},
+			mockGetObjectWithContext: func(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+				expected := &s3.GetObjectInput{
+					Bucket: aws.String("test_bucket"),
+					Key:    aws.String("test_prefix/foo"),
+					Range:  aws.String("bytes=2-4"),
+				}
+
+				if d := cmp.Diff(*expected, *input); d != "" {
+					t.Fatalf("unexpected input. %s", d)
+				}
+
+				return &s3.GetObjectOutput{
+					Body: io.NopCloser(bytes.NewReader([]byte("234"))),
+				}, nil
+			},
+			wantErr:  false,
+			wantBody: []byte("234"),
+		},
+		{
+			name: "range starts beyond end of object",
+			args: args{
+				filepath: "foo",
+				offset:   100,
+				length:   3,
+			},
+			mockGetObjectWithContext: func(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+				return nil, awserr.New(errCodeInvalidRange, "invalid range", nil)
+			},
+			wantErr:  false,
+			wantBody: []byte{},
+		},
+		{
+			name: "fail",
+			args: args{
+				filepath: "foo",
+				offset:   0,
+				length:   3,
+			},
+			mockGetObjectWithContext: func(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+				return nil, fmt.Errorf("error")
+			},
+			wantErr:  true,
+			wantBody: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			s3Provider := &S3{
+				bucketName: "test_bucket",
+				prefixPath: "test_prefix",
+				s3Service: &mockS3Client{
+					mockGetObjectWithContext: tc.mockGetObjectWithContext,
+				},
+			}
+
+			ctx := context.Background()
+			body, err := s3Provider.GetRange(ctx, tc.args.filepath, tc.args.offset, tc.args.length)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err: %v", err)
+			}
+
+			if d := cmp.Diff(tc.wantBody, body); d != "" {
+				t.Errorf("body was a mismatch. %s", d)
+			}
+		})
+	}
+}
+
+func TestS3SaveVersion(t *testing.T) {
+	s3Provider := &S3{
+		bucketName: "test_bucket",
+		prefixPath: "test_prefix",
+		s3Service: &mockS3Client{
+			mockPutObjectWithContext: func(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+				return &s3.PutObjectOutput{
+					VersionId: aws.String("v1"),
+				}, nil
+			},
+		},
+	}
+
+	ctx := context.Background()
+	versionID, err := s3Provider.SaveVersion(ctx, "foo", []byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if versionID != "v1" {
+		t.Fatalf("versionID was a mismatch. expected: v1, actual: %s", versionID)
+	}
+}
+
+func TestS3GetVersion(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		mockGetObjectWithContext func(aws.Context, *s3.GetObjectInput, ...request.Option) (*s3.GetObjectOutput, error)
+		wantBody                 []byte
+		wantErr                  bool
+	}{
+		{
+			name: "success",
+			mockGetObjectWithContext: func(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+				expected := &s3.GetObjectInput{
+					Bucket:    aws.String("test_bucket"),
+					Key:       aws.String("test_prefix/foo"),
+					VersionId: aws.String("v1"),
+				}
+
+				if d := cmp.Diff(*expected, *input); d != "" {
+					t.Fatalf("unexpected input. %s", d)
+				}
+
+				return &s3.GetObjectOutput{
+					Body: io.NopCloser(bytes.NewReader([]byte("test"))),
+				}, nil
+			},
+			wantBody: []byte("test"),
+		},
+		{
+			name: "version does not exist",
+			mockGetObjectWithContext: func(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+				return nil, awserr.New(errCodeNoSuchVersion, "no such version", nil)
+			},
+			wantBody: nil,
+		},
+		{
+			name: "fail",
+			mockGetObjectWithContext: func(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+				return nil, fmt.Errorf("error")
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			s3Provider := &S3{
+				bucketName: "test_bucket",
+				prefixPath: "test_prefix",
+				s3Service: &mockS3Client{
+					mockGetObjectWithContext: tc.mockGetObjectWithContext,
+				},
+			}
+
+			ctx := context.Background()
+			body, err := s3Provider.GetVersion(ctx, "foo", "v1")
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err: %v", err)
+			}
+
+			if d := cmp.Diff(tc.wantBody, body); d != "" {
+				t.Errorf("body was a mismatch. %s", d)
+			}
+		})
+	}
+}
+
+func TestS3DeleteVersion(t *testing.T) {
+	s3Provider := &S3{
+		bucketName: "test_bucket",
+		prefixPath: "test_prefix",
+		s3Service: &mockS3Client{
+			mockDeleteObjectWithContext: func(ctx aws.Context, input *s3.DeleteObjectInput, opts ...request.Option) (*s3.DeleteObjectOutput, error) {
+				expected := &s3.DeleteObjectInput{
+					Bucket:    aws.String("test_bucket"),
+					Key:       aws.String("test_prefix/foo"),
+					VersionId: aws.String("v1"),
+				}
+
+				if d := cmp.Diff(*expected, *input); d != "" {
+					t.Fatalf("unexpected input. %s", d)
+				}
+
+				return &s3.DeleteObjectOutput{}, nil
+			},
+		},
+	}
+
+	ctx := context.Background()
+	if err := s3Provider.DeleteVersion(ctx, "foo", "v1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestS3ListVersions(t *testing.T) {
+	older := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	s3Provider := &S3{
+		bucketName: "test_bucket",
+		prefixPath: "test_prefix",
+		s3Service: &mockS3Client{
+			mockListObjectVersionsPagesWithContext: func(ctx aws.Context, input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool, opts ...request.Option) error {
+				expected := &s3.ListObjectVersionsInput{
+					Bucket: aws.String("test_bucket"),
+					Prefix: aws.String("test_prefix/foo"),
+				}
+
+				if d := cmp.Diff(*expected, *input); d != "" {
+					t.Fatalf("unexpected input. %s", d)
+				}
+
+				fn(&s3.ListObjectVersionsOutput{
+					Versions: []*s3.ObjectVersion{
+						{Key: aws.String("test_prefix/foo"), VersionId: aws.String("v1"), Size: aws.Int64(4), LastModified: aws.Time(older)},
+						{Key: aws.String("test_prefix/foobar"), VersionId: aws.String("other"), Size: aws.Int64(4), LastModified: aws.Time(older)},
+					},
+					DeleteMarkers: []*s3.DeleteMarkerEntry{
+						{Key: aws.String("test_prefix/foo"), VersionId: aws.String("v2"), IsLatest: aws.Bool(true), LastModified: aws.Time(newer)},
+					},
+				}, true)
+
+				return nil
+			},
+		},
+	}
+
+	ctx := context.Background()
+	versions, err := s3Provider.ListVersions(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []object.Version{
+		{VersionID: "v2", IsLatest: true, IsDeleteMarker: true, LastModified: newer},
+		{VersionID: "v1", Size: 4, LastModified: older},
+	}
+	if d := cmp.Diff(expected, versions); d != "" {
+		t.Fatalf("unexpected versions. %s", d)
+	}
+}
+
+func TestS3SaveWithTTL(t *testing.T) {
+	s3Provider := &S3{
+		bucketName: "test_bucket",
+		prefixPath: "test_prefix",
+		clock:      func() time.Time { return time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC) },
+		s3Service: &mockS3Client{
+			mockPutObjectWithContext: func(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+				expected := &s3.PutObjectInput{
+					Bucket:  aws.String("test_bucket"),
+					Key:     aws.String("test_prefix/foo"),
+					Tagging: aws.String("expires-at=2022-01-02T12%3A00%3A00Z&ttl-days=2"),
+				}
+
+				opt := cmpopts.IgnoreFields(s3.PutObjectInput{}, "Body")
+				if d := cmp.Diff(*expected, *input, opt); d != "" {
+					t.Fatalf("unexpected input. %s", d)
+				}
+
+				return &s3.PutObjectOutput{}, nil
+			},
+		},
+	}
+
+	ctx := context.Background()
+	if _, err := s3Provider.Save(ctx, "foo", []byte("test"), option.SaveOptionWithTTL(36*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestS3Watch(t *testing.T) {
+	modified := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	listings := [][]*s3.Object{
+		{
+			{Key: aws.String("test_prefix/uploads/a"), ETag: aws.String("a1"), Size: aws.Int64(1), LastModified: aws.Time(modified)},
+			{Key: aws.String("test_prefix/uploads/b"), ETag: aws.String("b1"), Size: aws.Int64(1), LastModified: aws.Time(modified)},
+		},
+		{
+			{Key: aws.String("test_prefix/uploads/a"), ETag: aws.String("a2"), Size: aws.Int64(2), LastModified: aws.Time(modified)},
+			{Key: aws.String("test_prefix/uploads/c"), ETag: aws.String("c1"), Size: aws.Int64(1), LastModified: aws.Time(modified)},
+		},
+	}
+
+	calls := 0
+	s3Provider := &S3{
+		bucketName:    "test_bucket",
+		prefixPath:    "test_prefix",
+		watchInterval: time.Millisecond,
+		s3Service: &mockS3Client{
+			mockListObjectsV2PagesWithContext: func(ctx aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+				if aws.StringValue(input.Prefix) != "test_prefix/uploads/" {
+					t.Fatalf("unexpected prefix. %s", aws.StringValue(input.Prefix))
+				}
+
+				contents := listings[len(listings)-1]
+				if calls < len(listings) {
+					contents = listings[calls]
+				}
+				calls++
+
+				fn(&s3.ListObjectsV2Output{Contents: contents}, true)
+
+				return nil
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := s3Provider.Watch(ctx, "uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := map[string]object.EventType{}
+	for i := 0; i < 3; i++ {
+		select {
+		case event := <-ch:
+			actual[event.Info.Key] = event.Type
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+		}
+	}
+
+	expected := map[string]object.EventType{
+		"uploads/a": object.EventTypeUpdated,
+		"uploads/b": object.EventTypeDeleted,
+		"uploads/c": object.EventTypeCreated,
+	}
+	if d := cmp.Diff(expected, actual); d != "" {
+		t.Fatalf("unexpected events. %s", d)
+	}
+}
+
+func TestS3List(t *testing.T) {
+	modified := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s3Provider := &S3{
+		bucketName: "test_bucket",
+		prefixPath: "test_prefix",
+		s3Service: &mockS3Client{
+			mockListObjectsV2PagesWithContext: func(ctx aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+				expected := &s3.ListObjectsV2Input{
+					Bucket: aws.String("test_bucket"),
+					Prefix: aws.String("test_prefix/foo/"),
+				}
+
+				if d := cmp.Diff(*expected, *input); d != "" {
+					t.Fatalf("unexpected input. %s", d)
+				}
+
+				fn(&s3.ListObjectsV2Output{
+					Contents: []*s3.Object{
+						{Key: aws.String("test_prefix/foo/a"), ETag: aws.String(`"etag"`), Size: aws.Int64(4), LastModified: aws.Time(modified)},
+					},
+				}, true)
+
+				return nil
+			},
+		},
+	}
+
+	ctx := context.Background()
+	infos, err := s3Provider.List(ctx, "foo/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []object.Info{
+		{Key: "foo/a", Size: 4, LastModified: modified, ETag: `"etag"`},
+	}
+	if d := cmp.Diff(expected, infos); d != "" {
+		t.Fatalf("unexpected objects. %s", d)
+	}
+}
+
+func TestS3SaveWithContentTypeDetector(t *testing.T) {
+	testCases := []struct {
+		name            string
+		opts            []option.SaveOptionFunc
+		wantContentType string
+	}{
+		{
+			name:            "detect from extension",
+			wantContentType: "text/markdown",
+		},
+		{
+			name:            "content type set by option",
+			opts:            []option.SaveOptionFunc{option.SaveOptionWithContentType("text/plain")},
+			wantContentType: "text/plain",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			s3Provider := &S3{
+				bucketName: "test_bucket",
+				prefixPath: "test_prefix",
+				s3Service: &mockS3Client{
+					mockPutObjectWithContext: func(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+						if ct := aws.StringValue(input.ContentType); ct != tc.wantContentType {
+							t.Fatalf("content type was a mismatch. expected: %s, actual: %s", tc.wantContentType, ct)
+						}
+
+						return &s3.PutObjectOutput{}, nil
+					},
+				},
+			}
+			s3Provider.SetContentTypeDetector(contenttype.NewDetector(map[string]string{".md": "text/markdown"}))
+
+			ctx := context.Background()
+			if _, err := s3Provider.Save(ctx, "README.md", []byte("# test"), tc.opts...); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestS3Stat(t *testing.T) {
+	modified := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name                      string
+		mockHeadObjectWithContext func(aws.Context, *s3.HeadObjectInput, ...request.Option) (*s3.HeadObjectOutput, error)
+		wantInfo                  *object.Info
+		wantErr                   bool
+	}{
+		{
+			name: "success",
+			mockHeadObjectWithContext: func(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+				expected := &s3.HeadObjectInput{
+					Bucket: aws.String("test_bucket"),
+					Key:    aws.String("test_prefix/foo"),
+				}
+
+				if d := cmp.Diff(*expected, *input); d != "" {
+					t.Fatalf("unexpected input. %s", d)
+				}
+
+				return &s3.HeadObjectOutput{
+					ContentLength: aws.Int64(4),
+					LastModified:  aws.Time(modified),
+					ETag:          aws.String(`"etag"`),
+					ContentType:   aws.String("text/plain"),
+				}, nil
+			},
+			wantInfo: &object.Info{Key: "foo", Size: 4, LastModified: modified, ETag: `"etag"`, ContentType: "text/plain"},
+		},
+		{
+			name: "object does not exist",
+			mockHeadObjectWithContext: func(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+				return nil, awserr.New(errCodeNotFound, "not found", nil)
+			},
+			wantInfo: nil,
+		},
+		{
+			name: "fail",
+			mockHeadObjectWithContext: func(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+				return nil, fmt.Errorf("error")
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			s3Provider := &S3{
+				bucketName: "test_bucket",
+				prefixPath: "test_prefix",
+				s3Service: &mockS3Client{
+					mockHeadObjectWithContext: tc.mockHeadObjectWithContext,
+				},
+			}
+
+			ctx := context.Background()
+			info, err := s3Provider.Stat(ctx, "foo")
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err: %v", err)
+			}
+
+			if d := cmp.Diff(tc.wantInfo, info); d != "" {
+				t.Errorf("info was a mismatch. %s", d)
+			}
+		})
+	}
+}
+
+func TestS3Compose(t *testing.T) {
+	t.Parallel()
+
+	large := bytes.Repeat([]byte("a"), s3MinPartSize)
+
+	testCases := []struct {
+		name       string
+		objects    map[string][]byte
+		sources    []string
+		uploadErr  error
+		wantParts  [][]byte
+		wantAbort  bool
+		wantErr    bool
+		wantResult string
+	}{
+		{
+			name:       "small sources are merged into one part",
+			objects:    map[string][]byte{"test_prefix/a": []byte("foo"), "test_prefix/b": []byte("bar")},
+			sources:    []string{"a", "b"},
+			wantParts:  [][]byte{[]byte("foobar")},
+			wantResult: "s3://test_bucket/test_prefix/out",
+		},
+		{
+			name:       "a full part is flushed",
+			objects:    map[string][]byte{"test_prefix/a": large, "test_prefix/b": []byte("bar")},
+			sources:    []string{"a", "b"},
+			wantParts:  [][]byte{large, []byte("bar")},
+			wantResult: "s3://test_bucket/test_prefix/out",
+		},
+		{
+			name:      "missing source aborts the upload",
+			objects:   map[string][]byte{"test_prefix/a": []byte("foo")},
+			sources:   []string{"a", "b"},
+			wantAbort: true,
+			wantErr:   true,
+		},
+		{
+			name:      "failed part upload aborts the upload",
+			objects:   map[string][]byte{"test_prefix/a": []byte("foo")},
+			sources:   []string{"a"},
+			uploadErr: fmt.Errorf("error"),
+			wantAbort: true,
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				parts     [][]byte
+				completed []*s3.CompletedPart
+				aborted   bool
+			)
+
+			s3Provider := &S3{
+				bucketName: "test_bucket",
+				prefixPath: "test_prefix",
+				s3Service: &mockS3Client{
+					mockGetObjectWithContext: func(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+						data, ok := tc.objects[*input.Key]
+						if !ok {
+							return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)
+						}
+
+						return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+					},
+					mockCreateMultipartUploadWithContext: func(ctx aws.Context, input *s3.CreateMultipartUploadInput, opts ...request.Option) (*s3.CreateMultipartUploadOutput, error) {
+						return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload")}, nil
+					},
+					mockUploadPartWithContext: func(ctx aws.Context, input *s3.UploadPartInput, opts ...request.Option) (*s3.UploadPartOutput, error) {
+						if tc.uploadErr != nil {
+							return nil, tc.uploadErr
+						}
+
+						data, err := io.ReadAll(input.Body)
+						if err != nil {
+							return nil, err
+						}
+						parts = append(parts, data)
+
+						return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("etag-%d", *input.PartNumber))}, nil
+					},
+					mockCompleteMultipartUploadWithContext: func(ctx aws.Context, input *s3.CompleteMultipartUploadInput, opts ...request.Option) (*s3.CompleteMultipartUploadOutput, error) {
+						completed = input.MultipartUpload.Parts
+						return &s3.CompleteMultipartUploadOutput{}, nil
+					},
+					mockAbortMultipartUploadWithContext: func(ctx aws.Context, input *s3.AbortMultipartUploadInput, opts ...request.Option) (*s3.AbortMultipartUploadOutput, error) {
+						aborted = true
+						return &s3.AbortMultipartUploadOutput{}, nil
+					},
+				},
+			}
+
+			result, err := s3Provider.Compose(context.Background(), "out", tc.sources)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err: %v", err)
+			}
+
+			if result != tc.wantResult {
+				t.Errorf("result was a mismatch. expected: %s, actual: %s", tc.wantResult, result)
+			}
+
+			if aborted != tc.wantAbort {
+				t.Errorf("aborted was a mismatch. expected: %t, actual: %t", tc.wantAbort, aborted)
+			}
+
+			if tc.wantErr {
+				return
+			}
+
+			if len(parts) != len(tc.wantParts) {
+				t.Fatalf("number of parts was a mismatch. expected: %d, actual: %d", len(tc.wantParts), len(parts))
+			}
+
+			for i := range parts {
+				if !bytes.Equal(tc.wantParts[i], parts[i]) {
+					t.Errorf("part %d was a mismatch", i+1)
+				}
+			}
+
+			if len(completed) != len(tc.wantParts) {
+				t.Errorf("completed parts were a mismatch. %v", completed)
+			}
+		})
+	}
+}
+
+func TestS3Conditional(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		call       func(s *S3) error
+		err        error
+		wantHeader map[string]string
+		wantErr    error
+	}{
+		{
+			name: "save if absent",
+			call: func(s *S3) error {
+				_, err := s.SaveIfAbsent(context.Background(), "lock", []byte("test"))
+				return err
+			},
+			wantHeader: map[string]string{"If-None-Match": "*"},
+		},
+		{
+			name: "save if absent when the object exists",
+			call: func(s *S3) error {
+				_, err := s.SaveIfAbsent(context.Background(), "lock", []byte("test"))
+				return err
+			},
+			err:        awserr.New(errCodePreconditionFailed, "precondition failed", nil),
+			wantHeader: map[string]string{"If-None-Match": "*"},
+			wantErr:    ErrPreconditionFailed,
+		},
+		{
+			name: "save if match during a concurrent write",
+			call: func(s *S3) error {
+				_, err := s.SaveIfMatch(context.Background(), "lock", []byte("test"), `"etag"`)
+				return err
+			},
+			err:        awserr.New(errCodeConditionalRequestConflict, "conflict", nil),
+			wantHeader: map[string]string{"If-Match": `"etag"`},
+			wantErr:    ErrPreconditionFailed,
+		},
+		{
+			name: "delete if match",
+			call: func(s *S3) error {
+				return s.DeleteIfMatch(context.Background(), "lock", `"etag"`)
+			},
+			wantHeader: map[string]string{"If-Match": `"etag"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			header := http.Header{}
+			applyOptions := func(opts []request.Option) {
+				r := &request.Request{HTTPRequest: &http.Request{Header: header}}
+				for _, opt := range opts {
+					opt(r)
+				}
+			}
+
+			s3Provider := &S3{
+				bucketName: "test_bucket",
+				prefixPath: "test_prefix",
+				s3Service: &mockS3Client{
+					mockPutObjectWithContext: func(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+						applyOptions(opts)
+						if tc.err != nil {
+							return nil, tc.err
+						}
+
+						return &s3.PutObjectOutput{ETag: aws.String(`"new"`)}, nil
+					},
+					mockDeleteObjectWithContext: func(ctx aws.Context, input *s3.DeleteObjectInput, opts ...request.Option) (*s3.DeleteObjectOutput, error) {
+						applyOptions(opts)
+						if tc.err != nil {
+							return nil, tc.err
+						}
+
+						return &s3.DeleteObjectOutput{}, nil
+					},
+				},
+			}
+
+			if err := tc.call(s3Provider); !errors.Is(err, tc.wantErr) {
+				t.Errorf("err was a mismatch. expected: %v, actual: %v", tc.wantErr, err)
+			}
+
+			for k, v := range tc.wantHeader {
+				if header.Get(k) != v {
+					t.Errorf("%s header was a mismatch. expected: %s, actual: %s", k, v, header.Get(k))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+// defaultS3WatchInterval is the default interval at which Watch lists objects.
+const defaultS3WatchInterval = 30 * time.Second
+
+// SetWatchInterval sets the interval at which Watch lists objects.
+func (s *S3) SetWatchInterval(interval time.Duration) {
+	s.watchInterval = interval
+}
+
+// Watch notifies changes of objects whose key starts with prefix until ctx is canceled.
+// Changes are detected by listing the objects periodically and comparing their ETags.
+func (s *S3) Watch(ctx context.Context, prefix string) (<-chan object.Event, error) {
+	prev, err := s.snapshot(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := s.watchInterval
+	if interval <= 0 {
+		interval = defaultS3WatchInterval
+	}
+
+	ch := make(chan object.Event)
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		logger := log.FromContext(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			cur, err := s.snapshot(ctx, prefix)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				logger.Error(err, "failed to list objects", "prefix", prefix)
+				continue
+			}
+
+			for _, event := range diffSnapshots(prev, cur) {
+				select {
+				case <-ctx.Done():
+					return
+				case ch <- event:
+				}
+			}
+
+			prev = cur
+		}
+	}()
+
+	return ch, nil
+}
+
+func (s *S3) snapshot(ctx context.Context, prefix string) (map[string]object.Info, error) {
+	list, err := s.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make(map[string]object.Info, len(list))
+	for _, info := range list {
+		infos[info.Key] = info
+	}
+
+	return infos, nil
+}
+
+func diffSnapshots(prev, cur map[string]object.Info) []object.Event {
+	var events []object.Event
+
+	for key, info := range cur {
+		p, ok := prev[key]
+		switch {
+		case !ok:
+			events = append(events, object.Event{Type: object.EventTypeCreated, Info: info})
+		case p.ETag != info.ETag || !p.LastModified.Equal(info.LastModified):
+			events = append(events, object.Event{Type: object.EventTypeUpdated, Info: info})
+		}
+	}
+
+	for key := range prev {
+		if _, ok := cur[key]; !ok {
+			events = append(events, object.Event{Type: object.EventTypeDeleted, Info: object.Info{Key: key}})
+		}
+	}
+
+	return events
+}
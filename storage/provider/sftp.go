@@ -0,0 +1,413 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+const (
+	defaultSFTPMaxConns    = 4
+	defaultSFTPDialTimeout = 10 * time.Second
+)
+
+// ErrSFTPClosed is returned by the operations of SFTP after it is closed.
+var ErrSFTPClosed = errors.New("sftp storage is closed")
+
+// SFTPConfig is the config of SFTP.
+type SFTPConfig struct {
+	// Addr is the host:port of the server.
+	Addr string
+	User string
+	Auth []ssh.AuthMethod
+	// HostKeyCallback verifies the host key of the server, e.g. the one returned by knownhosts.New.
+	HostKeyCallback ssh.HostKeyCallback
+
+	// RootDir is the directory on the server under which the files are stored.
+	RootDir string
+	// MaxConns is the maximum number of connections to the server. It defaults to 4.
+	MaxConns int
+	// DialTimeout defaults to 10 seconds.
+	DialTimeout time.Duration
+}
+
+// SFTP stores files on an SFTP server.
+// Connections are pooled and a connection that is lost is replaced by a new one, retrying the operation once.
+// SaveOptions are ignored.
+type SFTP struct {
+	conf SFTPConfig
+
+	sem    chan struct{}
+	mu     sync.Mutex
+	idle   []*sftpConn
+	closed bool
+}
+
+type sftpConn struct {
+	ssh  *ssh.Client
+	sftp *sftp.Client
+}
+
+func (c *sftpConn) close() error {
+	return joinErrors(c.sftp.Close(), c.ssh.Close())
+}
+
+// NewSFTP returns SFTP. Connections are established lazily.
+func NewSFTP(conf SFTPConfig) (*SFTP, error) {
+	if conf.HostKeyCallback == nil {
+		return nil, errors.New("host key callback is required")
+	}
+
+	if conf.MaxConns <= 0 {
+		conf.MaxConns = defaultSFTPMaxConns
+	}
+
+	if conf.DialTimeout <= 0 {
+		conf.DialTimeout = defaultSFTPDialTimeout
+	}
+
+	return &SFTP{
+		conf: conf,
+		sem:  make(chan struct{}, conf.MaxConns),
+	}, nil
+}
+
+func (s *SFTP) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	fullPath := s.fileFullPath(filePath)
+
+	err := s.do(ctx, func(c *sftp.Client) error {
+		if err := c.MkdirAll(path.Dir(fullPath)); err != nil {
+			return err
+		}
+
+		f, err := c.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+		if err != nil {
+			return err
+		}
+
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+
+			return err
+		}
+
+		return f.Close()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("sftp://%s%s", s.conf.Addr, fullPath), nil
+}
+
+func (s *SFTP) Get(ctx context.Context, filePath string) ([]byte, error) {
+	var data []byte
+
+	err := s.do(ctx, func(c *sftp.Client) error {
+		f, err := c.Open(s.fileFullPath(filePath))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		data, err = io.ReadAll(f)
+
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetRange returns length bytes of the file starting at offset.
+// The returned slice is shorter than length when the file ends before the range does.
+func (s *SFTP) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if offset < 0 || length <= 0 {
+		return nil, fmt.Errorf("invalid range. offset: %d, length: %d", offset, length)
+	}
+
+	var data []byte
+
+	err := s.do(ctx, func(c *sftp.Client) error {
+		f, err := c.Open(s.fileFullPath(filePath))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		data, err = io.ReadAll(io.LimitReader(f, length))
+
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Stat returns nil when the file does not exist or is a directory.
+func (s *SFTP) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	var fi os.FileInfo
+
+	err := s.do(ctx, func(c *sftp.Client) error {
+		var err error
+		fi, err = c.Stat(s.fileFullPath(filePath))
+
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if fi.IsDir() {
+		return nil, nil
+	}
+
+	return &object.Info{
+		Key:          filePath,
+		Size:         fi.Size(),
+		LastModified: fi.ModTime(),
+	}, nil
+}
+
+// List returns files whose path starts with prefix, sorted by path.
+func (s *SFTP) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	root := s.conf.RootDir
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		root = s.fileFullPath(prefix[:i])
+	}
+
+	var infos []object.Info
+
+	err := s.do(ctx, func(c *sftp.Client) error {
+		infos = nil
+
+		walker := c.Walk(root)
+		for walker.Step() {
+			if err := walker.Err(); err != nil {
+				if walker.Path() == root && errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+
+				return err
+			}
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if walker.Stat().IsDir() {
+				continue
+			}
+
+			key := strings.TrimPrefix(walker.Path(), strings.TrimSuffix(s.conf.RootDir, "/")+"/")
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			infos = append(infos, object.Info{
+				Key:          key,
+				Size:         walker.Stat().Size(),
+				LastModified: walker.Stat().ModTime(),
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+
+	return infos, nil
+}
+
+func (s *SFTP) Delete(ctx context.Context, filePath string) error {
+	return s.do(ctx, func(c *sftp.Client) error {
+		return c.Remove(s.fileFullPath(filePath))
+	})
+}
+
+// Ping checks the root directory can be read.
+func (s *SFTP) Ping(ctx context.Context) error {
+	return s.do(ctx, func(c *sftp.Client) error {
+		_, err := c.Stat(s.conf.RootDir)
+
+		return err
+	})
+}
+
+// Close closes the idle connections. Connections in use are closed when they are released,
+// and the operations started after Close fail with ErrSFTPClosed.
+func (s *SFTP) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	idle := s.idle
+	s.idle = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for _, c := range idle {
+		errs = append(errs, c.close())
+	}
+
+	return joinErrors(errs...)
+}
+
+func (s *SFTP) fileFullPath(filePath string) string {
+	return path.Join(s.conf.RootDir, filePath)
+}
+
+// do runs fn with a pooled connection. When the connection turns out to be lost, fn is retried once with a new one.
+func (s *SFTP) do(ctx context.Context, fn func(*sftp.Client) error) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var c *sftpConn
+		c, err = s.acquire(ctx, attempt > 0)
+		if err != nil {
+			return err
+		}
+
+		err = fn(c.sftp)
+		lost := isSFTPConnectionLost(err)
+		s.release(c, lost)
+
+		if !lost {
+			return err
+		}
+	}
+
+	return err
+}
+
+// acquire returns an idle connection or dials a new one. fresh skips the idle connections.
+func (s *SFTP) acquire(ctx context.Context, fresh bool) (*sftpConn, error) {
+	select {
+	case s.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		<-s.sem
+
+		return nil, ErrSFTPClosed
+	}
+
+	if !fresh {
+		if n := len(s.idle); n > 0 {
+			c := s.idle[n-1]
+			s.idle = s.idle[:n-1]
+			s.mu.Unlock()
+
+			return c, nil
+		}
+	}
+	s.mu.Unlock()
+
+	c, err := s.dial(ctx)
+	if err != nil {
+		<-s.sem
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (s *SFTP) release(c *sftpConn, broken bool) {
+	defer func() { <-s.sem }()
+
+	s.mu.Lock()
+	if broken || s.closed {
+		s.mu.Unlock()
+		c.close()
+
+		return
+	}
+	s.idle = append(s.idle, c)
+	s.mu.Unlock()
+}
+
+func (s *SFTP) dial(ctx context.Context) (*sftpConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.conf.DialTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.conf.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s. %w", s.conf.Addr, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, s.conf.Addr, &ssh.ClientConfig{
+		User:            s.conf.User,
+		Auth:            s.conf.Auth,
+		HostKeyCallback: s.conf.HostKeyCallback,
+	})
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("failed to establish ssh connection to %s. %w", s.conf.Addr, err)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+
+		return nil, fmt.Errorf("failed to start sftp session. %w", err)
+	}
+
+	return &sftpConn{ssh: sshClient, sftp: sftpClient}, nil
+}
+
+func isSFTPConnectionLost(err error) bool {
+	return errors.Is(err, sftp.ErrSSHFxConnectionLost) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,353 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+// testSFTPServer is an in-process SFTP server serving the local filesystem.
+type testSFTPServer struct {
+	addr      string
+	hostKey   ssh.PublicKey
+	clientKey ssh.Signer
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newTestSFTPServer(t *testing.T) *testSFTPServer {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, clientPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientSigner, err := ssh.NewSignerFromKey(clientPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "test" && bytes.Equal(key.Marshal(), clientSigner.PublicKey().Marshal()) {
+				return nil, nil
+			}
+
+			return nil, errors.New("unauthorized")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	s := &testSFTPServer{
+		addr:      l.Addr().String(),
+		hostKey:   hostSigner.PublicKey(),
+		clientKey: clientSigner,
+	}
+	t.Cleanup(s.disconnect)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+
+			go serveSFTP(conn, config)
+		}
+	}()
+
+	return s
+}
+
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+
+				if !ok {
+					continue
+				}
+
+				server, err := sftp.NewServer(channel)
+				if err != nil {
+					channel.Close()
+					return
+				}
+				server.Serve()
+				server.Close()
+			}
+		}()
+	}
+}
+
+// disconnect closes all the connections accepted so far.
+func (s *testSFTPServer) disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testSFTPServer) numConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.conns)
+}
+
+func (s *testSFTPServer) provider(t *testing.T, rootDir string, maxConns int) *SFTP {
+	t.Helper()
+
+	p, err := NewSFTP(SFTPConfig{
+		Addr:            s.addr,
+		User:            "test",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(s.clientKey)},
+		HostKeyCallback: ssh.FixedHostKey(s.hostKey),
+		RootDir:         rootDir,
+		MaxConns:        maxConns,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+
+	return p
+}
+
+func TestSFTP(t *testing.T) {
+	server := newTestSFTPServer(t)
+	rootDir := t.TempDir()
+	p := server.provider(t, rootDir, 2)
+
+	ctx := context.Background()
+
+	if err := p.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for filePath, content := range map[string]string{"a.txt": "hello", "dir/b.txt": "world", "dir/sub/c.txt": "!"} {
+		savedPath, err := p.Save(ctx, filePath, []byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := "sftp://" + server.addr + rootDir + "/" + filePath; savedPath != want {
+			t.Errorf("savedPath was a mismatch. expected: %s, actual: %s", want, savedPath)
+		}
+	}
+
+	data, err := p.Get(ctx, "dir/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "world" {
+		t.Errorf("unexpected contents. %s", data)
+	}
+
+	data, err = p.Get(ctx, "missing.txt")
+	if err != nil || data != nil {
+		t.Errorf("missing file should return nil. data: %v, err: %v", data, err)
+	}
+
+	data, err = p.GetRange(ctx, "a.txt", 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "ell" {
+		t.Errorf("unexpected range. %s", data)
+	}
+
+	info, err := p.Stat(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(&object.Info{Key: "a.txt", Size: 5}, info, cmpopts.IgnoreFields(object.Info{}, "LastModified")); d != "" {
+		t.Errorf("info was a mismatch. %s", d)
+	}
+
+	infos, err := p.List(ctx, "dir/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for _, info := range infos {
+		keys = append(keys, info.Key)
+	}
+	if d := cmp.Diff([]string{"dir/b.txt", "dir/sub/c.txt"}, keys); d != "" {
+		t.Errorf("keys were a mismatch. %s", d)
+	}
+
+	if err := p.Delete(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = p.Get(ctx, "a.txt")
+	if err != nil || data != nil {
+		t.Errorf("deleted file should return nil. data: %v, err: %v", data, err)
+	}
+}
+
+func TestSFTPPool(t *testing.T) {
+	server := newTestSFTPServer(t)
+	p := server.provider(t, t.TempDir(), 2)
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := p.Save(ctx, "test.txt", []byte("test")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := server.numConns(); n > 2 {
+		t.Errorf("connections should be limited to 2 but %d were made", n)
+	}
+}
+
+func TestSFTPReconnect(t *testing.T) {
+	server := newTestSFTPServer(t)
+	p := server.provider(t, t.TempDir(), 1)
+
+	ctx := context.Background()
+
+	if _, err := p.Save(ctx, "test.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	server.disconnect()
+
+	data, err := p.Get(ctx, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "test" {
+		t.Errorf("unexpected contents. %s", data)
+	}
+}
+
+func TestSFTPClose(t *testing.T) {
+	server := newTestSFTPServer(t)
+	p := server.provider(t, t.TempDir(), 2)
+
+	ctx := context.Background()
+
+	if _, err := p.Save(ctx, "test.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	inUse, err := p.acquire(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p.release(inUse, false)
+	if len(p.idle) != 0 {
+		t.Errorf("connection released after Close should be closed. %d", len(p.idle))
+	}
+	if _, err := inUse.sftp.Getwd(); err == nil {
+		t.Error("connection released after Close should be closed")
+	}
+
+	if _, err := p.Save(ctx, "test.txt", []byte("test")); !errors.Is(err, ErrSFTPClosed) {
+		t.Errorf("Save after Close should fail. %v", err)
+	}
+	if _, err := p.Get(ctx, "test.txt"); !errors.Is(err, ErrSFTPClosed) {
+		t.Errorf("Get after Close should fail. %v", err)
+	}
+	if err := p.Ping(ctx); !errors.Is(err, ErrSFTPClosed) {
+		t.Errorf("Ping after Close should fail. %v", err)
+	}
+}
+
+func TestSFTPUnknownHostKey(t *testing.T) {
+	server := newTestSFTPServer(t)
+
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ssh.NewPublicKey(otherPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewSFTP(SFTPConfig{
+		Addr:            server.addr,
+		User:            "test",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(server.clientKey)},
+		HostKeyCallback: ssh.FixedHostKey(otherKey),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Ping(context.Background()); err == nil {
+		t.Fatal("unknown host key should be rejected")
+	}
+}
@@ -0,0 +1,446 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// WebDAVConfig is the config of WebDAV.
+type WebDAVConfig struct {
+	// URL is the collection under which the files are stored, e.g. https://example.com/remote.php/dav/files/user/reports.
+	URL string
+	// Username and Password are used for basic auth. They are ignored when BearerToken is set.
+	Username string
+	Password string
+	// BearerToken is sent in the Authorization header.
+	BearerToken string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// WebDAV stores files on a WebDAV server such as Nextcloud.
+// Collections are created by MKCOL when a file is saved to a collection that does not exist.
+type WebDAV struct {
+	baseURL     *url.URL
+	username    string
+	password    string
+	bearerToken string
+	client      *http.Client
+
+	contentTypeDetector *contenttype.Detector
+}
+
+func NewWebDAV(conf WebDAVConfig) (*WebDAV, error) {
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the url. %w", err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	client := conf.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &WebDAV{
+		baseURL:     u,
+		username:    conf.Username,
+		password:    conf.Password,
+		bearerToken: conf.BearerToken,
+		client:      client,
+	}, nil
+}
+
+// SetContentTypeDetector makes Save detect the content type by d when it is not set by option.SaveOptionWithContentType.
+func (w *WebDAV) SetContentTypeDetector(d *contenttype.Detector) {
+	w.contentTypeDetector = d
+}
+
+func (w *WebDAV) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	var saveOpt option.SaveOption
+	for _, opt := range opts {
+		opt(&saveOpt)
+	}
+
+	header := http.Header{}
+	if ct := contentType(&saveOpt, w.contentTypeDetector, filePath, data); ct != nil {
+		header.Set("Content-Type", *ct)
+	}
+	if saveOpt.ContentDisposition != nil {
+		header.Set("Content-Disposition", *saveOpt.ContentDisposition)
+	}
+
+	put := func() (*http.Response, error) {
+		return w.do(ctx, http.MethodPut, w.fileURL(filePath), header, bytes.NewReader(data))
+	}
+
+	res, err := put()
+	if err != nil {
+		return "", err
+	}
+	res.Body.Close()
+
+	// servers respond 409 or 404 when the parent collection does not exist
+	if res.StatusCode == http.StatusConflict || res.StatusCode == http.StatusNotFound {
+		if err := w.mkcolAll(ctx, path.Dir(filePath)); err != nil {
+			return "", err
+		}
+
+		res, err = put()
+		if err != nil {
+			return "", err
+		}
+		res.Body.Close()
+	}
+
+	if !isSuccessStatus(res.StatusCode) {
+		return "", webDAVStatusError(http.MethodPut, filePath, res)
+	}
+
+	return w.fileURL(filePath), nil
+}
+
+func (w *WebDAV) Get(ctx context.Context, filePath string) ([]byte, error) {
+	res, err := w.do(ctx, http.MethodGet, w.fileURL(filePath), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, webDAVStatusError(http.MethodGet, filePath, res)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+// GetRange returns length bytes of the file starting at offset.
+// The returned slice is shorter than length when the file ends before the range does.
+func (w *WebDAV) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	if offset < 0 || length <= 0 {
+		return nil, fmt.Errorf("invalid range. offset: %d, length: %d", offset, length)
+	}
+
+	header := http.Header{}
+	header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+
+	res, err := w.do(ctx, http.MethodGet, w.fileURL(filePath), header, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return nil, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		return []byte{}, nil
+	case http.StatusPartialContent:
+		return io.ReadAll(io.LimitReader(res.Body, length))
+	case http.StatusOK:
+		// the server ignored the range
+		if _, err := io.CopyN(io.Discard, res.Body, offset); err != nil {
+			if err == io.EOF {
+				return []byte{}, nil
+			}
+
+			return nil, err
+		}
+
+		return io.ReadAll(io.LimitReader(res.Body, length))
+	default:
+		return nil, webDAVStatusError(http.MethodGet, filePath, res)
+	}
+}
+
+// Stat returns nil when the file does not exist or is a collection.
+func (w *WebDAV) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	resources, err := w.propfind(ctx, w.fileURL(filePath), "0")
+	if err != nil || len(resources) == 0 {
+		return nil, err
+	}
+
+	if resources[0].collection {
+		return nil, nil
+	}
+
+	info := resources[0].info
+	info.Key = filePath
+
+	return &info, nil
+}
+
+// List returns files whose path starts with prefix, sorted by path.
+// Collections are walked one level at a time since many servers disable PROPFIND with infinite depth.
+func (w *WebDAV) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	dir := ""
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		dir = prefix[:i]
+	}
+
+	var infos []object.Info
+
+	queue := []string{dir}
+	for len(queue) > 0 {
+		dir, queue = queue[0], queue[1:]
+
+		resources, err := w.propfind(ctx, w.collectionURL(dir), "1")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range resources {
+			if r.key == dir {
+				continue
+			}
+
+			if r.collection {
+				if strings.HasPrefix(r.key+"/", prefix) || strings.HasPrefix(prefix, r.key+"/") {
+					queue = append(queue, r.key)
+				}
+
+				continue
+			}
+
+			if strings.HasPrefix(r.key, prefix) {
+				info := r.info
+				info.Key = r.key
+				infos = append(infos, info)
+			}
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+
+	return infos, nil
+}
+
+func (w *WebDAV) Delete(ctx context.Context, filePath string) error {
+	res, err := w.do(ctx, http.MethodDelete, w.fileURL(filePath), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if !isSuccessStatus(res.StatusCode) {
+		return webDAVStatusError(http.MethodDelete, filePath, res)
+	}
+
+	return nil
+}
+
+// Ping checks the properties of the base collection can be read.
+func (w *WebDAV) Ping(ctx context.Context) error {
+	resources, err := w.propfind(ctx, w.collectionURL(""), "0")
+	if err != nil {
+		return err
+	}
+
+	if len(resources) == 0 {
+		return fmt.Errorf("%s does not exist", w.baseURL.Redacted())
+	}
+
+	return nil
+}
+
+// mkcolAll creates the collection and its parents.
+func (w *WebDAV) mkcolAll(ctx context.Context, dir string) error {
+	if dir == "." || dir == "" {
+		return nil
+	}
+
+	var current string
+	for _, name := range strings.Split(dir, "/") {
+		current = path.Join(current, name)
+
+		res, err := w.do(ctx, "MKCOL", w.collectionURL(current), nil, nil)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+
+		// 405 is returned when the collection already exists
+		if !isSuccessStatus(res.StatusCode) && res.StatusCode != http.StatusMethodNotAllowed {
+			return webDAVStatusError("MKCOL", current, res)
+		}
+	}
+
+	return nil
+}
+
+const webDAVPropfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:">
+  <d:prop>
+    <d:resourcetype/>
+    <d:getcontentlength/>
+    <d:getlastmodified/>
+    <d:getetag/>
+    <d:getcontenttype/>
+  </d:prop>
+</d:propfind>`
+
+type webDAVMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ContentLength string `xml:"DAV: getcontentlength"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+				ETag          string `xml:"DAV: getetag"`
+				ContentType   string `xml:"DAV: getcontenttype"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+type webDAVResource struct {
+	key        string
+	collection bool
+	info       object.Info
+}
+
+// propfind returns nil when the resource does not exist.
+func (w *WebDAV) propfind(ctx context.Context, u string, depth string) ([]webDAVResource, error) {
+	header := http.Header{}
+	header.Set("Depth", depth)
+	header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	res, err := w.do(ctx, "PROPFIND", u, header, strings.NewReader(webDAVPropfindBody))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if res.StatusCode != http.StatusMultiStatus {
+		return nil, webDAVStatusError("PROPFIND", u, res)
+	}
+
+	var ms webDAVMultistatus
+	if err := xml.NewDecoder(res.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("failed to decode the PROPFIND response. %w", err)
+	}
+
+	resources := make([]webDAVResource, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		key, err := w.hrefKey(r.Href)
+		if err != nil {
+			return nil, err
+		}
+
+		resource := webDAVResource{key: key}
+		for _, ps := range r.Propstats {
+			if !strings.Contains(ps.Status, " 200 ") {
+				continue
+			}
+
+			resource.collection = ps.Prop.ResourceType.Collection != nil
+			resource.info.ETag = ps.Prop.ETag
+			resource.info.ContentType = ps.Prop.ContentType
+
+			if ps.Prop.ContentLength != "" {
+				if resource.info.Size, err = strconv.ParseInt(ps.Prop.ContentLength, 10, 64); err != nil {
+					return nil, fmt.Errorf("invalid content length of %s. %w", key, err)
+				}
+			}
+
+			if ps.Prop.LastModified != "" {
+				if resource.info.LastModified, err = http.ParseTime(ps.Prop.LastModified); err != nil {
+					return nil, fmt.Errorf("invalid last modified of %s. %w", key, err)
+				}
+			}
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+// hrefKey converts the href of a PROPFIND response, which is either an absolute path or a URL, into the key.
+func (w *WebDAV) hrefKey(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid href %s. %w", href, err)
+	}
+
+	p := strings.TrimSuffix(u.Path, "/")
+	if p == w.baseURL.Path {
+		return "", nil
+	}
+
+	key := strings.TrimPrefix(p, w.baseURL.Path+"/")
+	if key == p {
+		return "", fmt.Errorf("href %s is outside of %s", href, w.baseURL.Path)
+	}
+
+	return key, nil
+}
+
+func (w *WebDAV) fileURL(filePath string) string {
+	u := *w.baseURL
+	u.Path = path.Join(w.baseURL.Path, filePath)
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	return u.String()
+}
+
+func (w *WebDAV) collectionURL(dir string) string {
+	return strings.TrimSuffix(w.fileURL(dir), "/") + "/"
+}
+
+func (w *WebDAV) do(ctx context.Context, method, u string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	switch {
+	case w.bearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+w.bearerToken)
+	case w.username != "":
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	return w.client.Do(req)
+}
+
+func isSuccessStatus(code int) bool {
+	return code >= 200 && code < 300
+}
+
+func webDAVStatusError(method, filePath string, res *http.Response) error {
+	return fmt.Errorf("failed to %s %s. status: %s", method, filePath, res.Status)
+}
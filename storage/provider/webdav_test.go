@@ -0,0 +1,210 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/webdav"
+
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// newTestWebDAVServer serves an in-memory WebDAV file system under /dav/ and records the content type of PUT requests.
+func newTestWebDAVServer(t *testing.T, authorized func(r *http.Request) bool) (*httptest.Server, func(p string) string) {
+	t.Helper()
+
+	var (
+		mu           sync.Mutex
+		contentTypes = map[string]string{}
+	)
+
+	handler := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			mu.Lock()
+			contentTypes[r.URL.Path] = r.Header.Get("Content-Type")
+			mu.Unlock()
+		}
+
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func(p string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return contentTypes[p]
+	}
+}
+
+func TestWebDAV(t *testing.T) {
+	server, contentTypeOf := newTestWebDAVServer(t, func(r *http.Request) bool {
+		user, password, ok := r.BasicAuth()
+
+		return ok && user == "user" && password == "password"
+	})
+
+	// the base collection has to exist like the home directory of a Nextcloud user
+	req, err := http.NewRequest("MKCOL", server.URL+"/dav/reports/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("user", "password")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	p, err := NewWebDAV(WebDAVConfig{
+		URL:      server.URL + "/dav/reports",
+		Username: "user",
+		Password: "password",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := p.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	savedPath, err := p.Save(ctx, "2022/01/report 1.csv", []byte("a,b\n1,2\n"), option.SaveOptionWithContentType("text/csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := server.URL + "/dav/reports/2022/01/report%201.csv"; savedPath != want {
+		t.Errorf("savedPath was a mismatch. expected: %s, actual: %s", want, savedPath)
+	}
+
+	if ct := contentTypeOf("/dav/reports/2022/01/report 1.csv"); ct != "text/csv" {
+		t.Errorf("content type was not sent. %s", ct)
+	}
+
+	for filePath, content := range map[string]string{"2022/02/report.txt": "feb", "summary.txt": "summary"} {
+		if _, err := p.Save(ctx, filePath, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := p.Get(ctx, "2022/01/report 1.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a,b\n1,2\n" {
+		t.Errorf("unexpected contents. %s", data)
+	}
+
+	data, err = p.Get(ctx, "missing.txt")
+	if err != nil || data != nil {
+		t.Errorf("missing file should return nil. data: %v, err: %v", data, err)
+	}
+
+	data, err = p.GetRange(ctx, "summary.txt", 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "umm" {
+		t.Errorf("unexpected range. %s", data)
+	}
+
+	info, err := p.Stat(ctx, "summary.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || info.Key != "summary.txt" || info.Size != 7 || info.LastModified.IsZero() {
+		t.Errorf("unexpected info. %+v", info)
+	}
+
+	info, err = p.Stat(ctx, "2022")
+	if err != nil || info != nil {
+		t.Errorf("collection should not be an object. info: %v, err: %v", info, err)
+	}
+
+	testCases := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "", want: []string{"2022/01/report 1.csv", "2022/02/report.txt", "summary.txt"}},
+		{prefix: "2022/0", want: []string{"2022/01/report 1.csv", "2022/02/report.txt"}},
+		{prefix: "2022/02/", want: []string{"2022/02/report.txt"}},
+		{prefix: "missing/", want: nil},
+	}
+
+	for _, tc := range testCases {
+		infos, err := p.List(ctx, tc.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []string
+		for _, info := range infos {
+			keys = append(keys, info.Key)
+		}
+
+		if d := cmp.Diff(tc.want, keys); d != "" {
+			t.Errorf("keys with prefix %q were a mismatch. %s", tc.prefix, d)
+		}
+	}
+
+	if err := p.Delete(ctx, "summary.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = p.Get(ctx, "summary.txt")
+	if err != nil || data != nil {
+		t.Errorf("deleted file should return nil. data: %v, err: %v", data, err)
+	}
+}
+
+func TestWebDAVBearerAuth(t *testing.T) {
+	server, _ := newTestWebDAVServer(t, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer token"
+	})
+
+	testCases := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid token", token: "token"},
+		{name: "invalid token", token: "invalid", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewWebDAV(WebDAVConfig{
+				URL:         server.URL + "/dav",
+				BearerToken: tc.token,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = p.Save(context.Background(), "test.txt", []byte("test"))
+			if (err != nil) != tc.wantErr {
+				t.Errorf("err: %v", err)
+			}
+		})
+	}
+}
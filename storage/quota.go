@@ -0,0 +1,397 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+const (
+	defaultQuotaLevel    = 1
+	defaultQuotaUsageKey = ".quota/usage.json"
+)
+
+// QuotaLimit limits the usage of a prefix. A limit <= 0 disables it.
+type QuotaLimit struct {
+	MaxBytes   int64
+	MaxObjects int64
+}
+
+// Usage is the amount of data stored under a prefix.
+type Usage struct {
+	Bytes   int64 `json:"bytes"`
+	Objects int64 `json:"objects"`
+}
+
+// QuotaExceededError is returned when a Save would make the usage of a prefix exceed its limit.
+type QuotaExceededError struct {
+	Prefix   string
+	FilePath string
+	Limit    QuotaLimit
+	// Usage is the usage the Save would have resulted in.
+	Usage Usage
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("save %s: quota of %q exceeded. bytes: %d/%d, objects: %d/%d",
+		e.FilePath, e.Prefix, e.Usage.Bytes, e.Limit.MaxBytes, e.Usage.Objects, e.Limit.MaxObjects)
+}
+
+// Quota wraps a Storage and accounts the bytes and the number of objects per prefix,
+// rejecting Saves which exceed the limit of the prefix with *QuotaExceededError.
+// The prefix of a key is its first directories up to the level, e.g. "tenant-a" for "tenant-a/reports/1.csv" at level 1.
+//
+// Usage is counted in memory from the operations through Quota, so concurrent writes to the same key
+// or writes bypassing Quota make it drift. Recompute corrects it by scanning the storage.
+// The usage is persisted as JSON to the usage key of the same storage by Persist and loaded by Load.
+type Quota struct {
+	storage Storage
+
+	level        int
+	usageKey     string
+	defaultLimit QuotaLimit
+	limits       map[string]QuotaLimit
+
+	mu     sync.Mutex
+	usages map[string]Usage
+	dirty  bool
+}
+
+type QuotaOptionFunc func(q *Quota)
+
+// QuotaOptionWithLevel sets how many leading directories of keys form the prefix. It defaults to 1.
+func QuotaOptionWithLevel(level int) QuotaOptionFunc {
+	return func(q *Quota) {
+		q.level = level
+	}
+}
+
+// QuotaOptionWithLimit sets the limit of the prefix.
+func QuotaOptionWithLimit(prefix string, limit QuotaLimit) QuotaOptionFunc {
+	return func(q *Quota) {
+		q.limits[prefix] = limit
+	}
+}
+
+// QuotaOptionWithDefaultLimit sets the limit of prefixes without their own limit.
+func QuotaOptionWithDefaultLimit(limit QuotaLimit) QuotaOptionFunc {
+	return func(q *Quota) {
+		q.defaultLimit = limit
+	}
+}
+
+// QuotaOptionWithUsageKey sets the key the usage is persisted to. It defaults to ".quota/usage.json".
+func QuotaOptionWithUsageKey(key string) QuotaOptionFunc {
+	return func(q *Quota) {
+		q.usageKey = key
+	}
+}
+
+func NewQuota(s Storage, opts ...QuotaOptionFunc) *Quota {
+	q := &Quota{
+		storage:  s,
+		level:    defaultQuotaLevel,
+		usageKey: defaultQuotaUsageKey,
+		limits:   map[string]QuotaLimit{},
+		usages:   map[string]Usage{},
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
+}
+
+// Usage returns the usage of the prefix.
+func (q *Quota) Usage(prefix string) Usage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.usages[prefix]
+}
+
+// Usages returns the usage of every prefix.
+func (q *Quota) Usages() map[string]Usage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usages := make(map[string]Usage, len(q.usages))
+	for prefix, u := range q.usages {
+		usages[prefix] = u
+	}
+
+	return usages
+}
+
+func (q *Quota) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	if filePath == q.usageKey {
+		return q.storage.Save(ctx, filePath, data, opts...)
+	}
+
+	prevSize, exists, err := q.objectSize(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	delta := Usage{Bytes: int64(len(data)) - prevSize}
+	if !exists {
+		delta.Objects = 1
+	}
+
+	prefix := q.prefixOf(filePath)
+
+	if err := q.reserve(prefix, filePath, delta); err != nil {
+		return "", err
+	}
+
+	savedPath, err := q.storage.Save(ctx, filePath, data, opts...)
+	if err != nil {
+		q.add(prefix, Usage{Bytes: -delta.Bytes, Objects: -delta.Objects})
+
+		return "", err
+	}
+
+	return savedPath, nil
+}
+
+func (q *Quota) Get(ctx context.Context, filePath string) ([]byte, error) {
+	return q.storage.Get(ctx, filePath)
+}
+
+func (q *Quota) Delete(ctx context.Context, filePath string) error {
+	if filePath == q.usageKey {
+		return q.storage.Delete(ctx, filePath)
+	}
+
+	size, exists, err := q.objectSize(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := q.storage.Delete(ctx, filePath); err != nil {
+		return err
+	}
+
+	if exists {
+		q.add(q.prefixOf(filePath), Usage{Bytes: -size, Objects: -1})
+	}
+
+	return nil
+}
+
+func (q *Quota) Ping(ctx context.Context) error {
+	return q.storage.Ping(ctx)
+}
+
+func (q *Quota) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	rg, ok := q.storage.(RangeGetter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support range reads: %w", q.storage, ErrNotSupported)
+	}
+
+	return rg.GetRange(ctx, filePath, offset, length)
+}
+
+func (q *Quota) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	return stat(ctx, q.storage, filePath)
+}
+
+// List lists objects except the persisted usage.
+func (q *Quota) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	infos, err := list(ctx, q.storage, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]object.Info, 0, len(infos))
+	for _, info := range infos {
+		if info.Key == q.usageKey {
+			continue
+		}
+
+		filtered = append(filtered, info)
+	}
+
+	return filtered, nil
+}
+
+// Load replaces the usage with the persisted one. The usage is left as is when nothing was persisted.
+func (q *Quota) Load(ctx context.Context) error {
+	data, err := q.storage.Get(ctx, q.usageKey)
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	usages := map[string]Usage{}
+	if err := json.Unmarshal(data, &usages); err != nil {
+		return fmt.Errorf("failed to decode the usage %s. %w", q.usageKey, err)
+	}
+
+	q.mu.Lock()
+	q.usages = usages
+	q.dirty = false
+	q.mu.Unlock()
+
+	return nil
+}
+
+// Persist saves the usage to the usage key.
+func (q *Quota) Persist(ctx context.Context) error {
+	q.mu.Lock()
+	data, err := json.Marshal(q.usages)
+	q.dirty = false
+	q.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to encode the usage. %w", err)
+	}
+
+	if _, err := q.storage.Save(ctx, q.usageKey, data, option.SaveOptionWithContentType("application/json")); err != nil {
+		q.mu.Lock()
+		q.dirty = true
+		q.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// StartPersister starts a goroutine that persists the usage every interval when it changed, until ctx is canceled.
+func (q *Quota) StartPersister(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				q.mu.Lock()
+				dirty := q.dirty
+				q.mu.Unlock()
+
+				if !dirty {
+					continue
+				}
+
+				if err := q.Persist(ctx); err != nil {
+					log.FromContext(ctx).Error(err, "failed to persist the usage")
+				}
+			}
+		}
+	}()
+}
+
+// Recompute replaces the usage with the one counted by listing all objects of the storage, persists and returns it.
+// The storage must implement Lister.
+func (q *Quota) Recompute(ctx context.Context) (map[string]Usage, error) {
+	infos, err := list(ctx, q.storage, "")
+	if err != nil {
+		return nil, err
+	}
+
+	usages := map[string]Usage{}
+	for _, info := range infos {
+		if info.Key == q.usageKey {
+			continue
+		}
+
+		prefix := q.prefixOf(info.Key)
+		u := usages[prefix]
+		u.Bytes += info.Size
+		u.Objects++
+		usages[prefix] = u
+	}
+
+	q.mu.Lock()
+	q.usages = usages
+	q.dirty = true
+	q.mu.Unlock()
+
+	if err := q.Persist(ctx); err != nil {
+		return nil, err
+	}
+
+	return q.Usages(), nil
+}
+
+// reserve adds delta to the usage of prefix unless it makes the usage exceed the limit.
+// Saves which do not increase the usage are always allowed so that tenants over the limit can shrink.
+func (q *Quota) reserve(prefix, filePath string, delta Usage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	limit, ok := q.limits[prefix]
+	if !ok {
+		limit = q.defaultLimit
+	}
+
+	u := q.usages[prefix]
+	next := Usage{Bytes: u.Bytes + delta.Bytes, Objects: u.Objects + delta.Objects}
+
+	if (delta.Bytes > 0 && limit.MaxBytes > 0 && next.Bytes > limit.MaxBytes) ||
+		(delta.Objects > 0 && limit.MaxObjects > 0 && next.Objects > limit.MaxObjects) {
+		return &QuotaExceededError{Prefix: prefix, FilePath: filePath, Limit: limit, Usage: next}
+	}
+
+	q.usages[prefix] = next
+	q.dirty = true
+
+	return nil
+}
+
+func (q *Quota) add(prefix string, delta Usage) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	u := q.usages[prefix]
+	u.Bytes += delta.Bytes
+	u.Objects += delta.Objects
+	q.usages[prefix] = u
+	q.dirty = true
+}
+
+// prefixOf returns the first directories of filePath up to the level. Keys at the root belong to "".
+func (q *Quota) prefixOf(filePath string) string {
+	dirs := strings.Split(strings.TrimPrefix(filePath, "/"), "/")
+	dirs = dirs[:len(dirs)-1]
+
+	if len(dirs) > q.level {
+		dirs = dirs[:q.level]
+	}
+
+	return strings.Join(dirs, "/")
+}
+
+// objectSize returns the size of the object and whether it exists, by Stat when the storage implements Stater.
+func (q *Quota) objectSize(ctx context.Context, filePath string) (int64, bool, error) {
+	if st, ok := q.storage.(Stater); ok {
+		info, err := st.Stat(ctx, filePath)
+		if err != nil || info == nil {
+			return 0, false, err
+		}
+
+		return info.Size, true, nil
+	}
+
+	data, err := q.storage.Get(ctx, filePath)
+	if err != nil || data == nil {
+		return 0, false, err
+	}
+
+	return int64(len(data)), true, nil
+}
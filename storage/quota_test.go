@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestQuota(t *testing.T) {
+	ctx := context.Background()
+
+	memoryProvider := provider.NewMemory()
+	q := NewQuota(memoryProvider,
+		QuotaOptionWithDefaultLimit(QuotaLimit{MaxBytes: 10}),
+		QuotaOptionWithLimit("tenant-b", QuotaLimit{MaxObjects: 2}),
+	)
+
+	testCases := []struct {
+		name     string
+		filePath string
+		data     string
+		wantErr  bool
+	}{
+		{name: "first object", filePath: "tenant-a/1.txt", data: "12345"},
+		{name: "second object", filePath: "tenant-a/dir/2.txt", data: "12345"},
+		{name: "exceeding bytes", filePath: "tenant-a/3.txt", data: "1", wantErr: true},
+		{name: "shrinking overwrite", filePath: "tenant-a/1.txt", data: "123"},
+		{name: "fitting object", filePath: "tenant-a/3.txt", data: "12"},
+		{name: "other tenant", filePath: "tenant-b/1.txt", data: "123456789012345"},
+		{name: "object count", filePath: "tenant-b/2.txt", data: "1"},
+		{name: "exceeding object count", filePath: "tenant-b/3.txt", data: "1", wantErr: true},
+		{name: "root object", filePath: "root.txt", data: "1"},
+	}
+
+	for _, tc := range testCases {
+		_, err := q.Save(ctx, tc.filePath, []byte(tc.data))
+
+		var quotaErr *QuotaExceededError
+		if tc.wantErr != errors.As(err, &quotaErr) {
+			t.Fatalf("%s: unexpected error. %v", tc.name, err)
+		}
+	}
+
+	if err := q.Delete(ctx, "tenant-b/2.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]Usage{
+		"tenant-a": {Bytes: 10, Objects: 3},
+		"tenant-b": {Bytes: 15, Objects: 1},
+		"":         {Bytes: 1, Objects: 1},
+	}
+	if d := cmp.Diff(want, q.Usages()); d != "" {
+		t.Fatalf("usages were a mismatch. %s", d)
+	}
+
+	if err := q.Persist(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := q.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 5 {
+		t.Errorf("the usage should be hidden. %+v", infos)
+	}
+
+	loaded := NewQuota(memoryProvider)
+	if err := loaded.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(want, loaded.Usages()); d != "" {
+		t.Errorf("loaded usages were a mismatch. %s", d)
+	}
+
+	// a write bypassing the wrapper is only counted by Recompute
+	if _, err := memoryProvider.Save(ctx, "tenant-c/1.txt", []byte("123")); err != nil {
+		t.Fatal(err)
+	}
+
+	usages, err := loaded.Recompute(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want["tenant-c"] = Usage{Bytes: 3, Objects: 1}
+	if d := cmp.Diff(want, usages); d != "" {
+		t.Errorf("recomputed usages were a mismatch. %s", d)
+	}
+}
+
+func TestQuotaPrefixOf(t *testing.T) {
+	testCases := []struct {
+		level    int
+		filePath string
+		want     string
+	}{
+		{level: 1, filePath: "a/b/c.txt", want: "a"},
+		{level: 2, filePath: "a/b/c.txt", want: "a/b"},
+		{level: 2, filePath: "a/c.txt", want: "a"},
+		{level: 1, filePath: "c.txt", want: ""},
+	}
+
+	for _, tc := range testCases {
+		q := NewQuota(provider.NewMemory(), QuotaOptionWithLevel(tc.level))
+
+		if actual := q.prefixOf(tc.filePath); actual != tc.want {
+			t.Errorf("prefix of %s at level %d was a mismatch. expected: %s, actual: %s", tc.filePath, tc.level, tc.want, actual)
+		}
+	}
+}
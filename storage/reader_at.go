@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// RangeGetter is implemented by storages that can read a part of an object.
+type RangeGetter interface {
+	GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error)
+}
+
+type readerAt struct {
+	ctx      context.Context
+	rg       RangeGetter
+	filePath string
+}
+
+// NewReaderAt returns an io.ReaderAt that reads filePath through GetRange.
+func NewReaderAt(ctx context.Context, rg RangeGetter, filePath string) io.ReaderAt {
+	return &readerAt{
+		ctx:      ctx,
+		rg:       rg,
+		filePath: filePath,
+	}
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	b, err := r.rg.GetRange(r.ctx, r.filePath, off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+
+	if b == nil {
+		return 0, errors.New("file does not exist")
+	}
+
+	n := copy(p, b)
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
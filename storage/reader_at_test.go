@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestReaderAt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+
+	diskProvider := provider.NewDisk(dir)
+	if _, err := diskProvider.Save(ctx, "test.txt", []byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		filePath string
+		size     int
+		offset   int64
+
+		want    []byte
+		wantErr error
+	}{
+		{
+			name:     "read middle of file",
+			filePath: "test.txt",
+			size:     3,
+			offset:   2,
+			want:     []byte("234"),
+		},
+		{
+			name:     "read across end of file",
+			filePath: "test.txt",
+			size:     5,
+			offset:   8,
+			want:     []byte("89"),
+			wantErr:  io.EOF,
+		},
+		{
+			name:     "read beyond end of file",
+			filePath: "test.txt",
+			size:     5,
+			offset:   20,
+			want:     []byte{},
+			wantErr:  io.EOF,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ra := NewReaderAt(ctx, diskProvider, tc.filePath)
+
+			p := make([]byte, tc.size)
+			n, err := ra.ReadAt(p, tc.offset)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err: %v", err)
+			}
+
+			if d := cmp.Diff(tc.want, p[:n]); d != "" {
+				t.Fatalf("unexpected contents. %s", d)
+			}
+		})
+	}
+
+	if _, err := NewReaderAt(ctx, diskProvider, "missing.txt").ReadAt(make([]byte, 1), 0); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
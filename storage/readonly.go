@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// ReadOnlyError is returned when a mutation is requested to a read-only storage.
+type ReadOnlyError struct {
+	Op       string
+	FilePath string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("%s %s: storage is read-only", e.Op, e.FilePath)
+}
+
+type readOnly struct {
+	storage Storage
+}
+
+// ReadOnly wraps s so that Save and Delete fail with *ReadOnlyError.
+// Ping only checks the storage is readable by getting a file.
+func ReadOnly(s Storage) Storage {
+	return &readOnly{
+		storage: s,
+	}
+}
+
+func (r *readOnly) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	return "", &ReadOnlyError{Op: "save", FilePath: filePath}
+}
+
+func (r *readOnly) Get(ctx context.Context, filePath string) ([]byte, error) {
+	return r.storage.Get(ctx, filePath)
+}
+
+func (r *readOnly) Delete(ctx context.Context, filePath string) error {
+	return &ReadOnlyError{Op: "delete", FilePath: filePath}
+}
+
+func (r *readOnly) Ping(ctx context.Context) error {
+	_, err := r.storage.Get(ctx, "ping")
+
+	return err
+}
+
+func (r *readOnly) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	rg, ok := r.storage.(RangeGetter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support range reads: %w", r.storage, ErrNotSupported)
+	}
+
+	return rg.GetRange(ctx, filePath, offset, length)
+}
+
+func (r *readOnly) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	return stat(ctx, r.storage, filePath)
+}
+
+func (r *readOnly) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	return list(ctx, r.storage, prefix)
+}
+
+func (r *readOnly) Watch(ctx context.Context, prefix string) (<-chan object.Event, error) {
+	w, ok := r.storage.(Watcher)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support watching: %w", r.storage, ErrNotSupported)
+	}
+
+	return w.Watch(ctx, prefix)
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestReadOnly(t *testing.T) {
+	ctx := context.Background()
+
+	memoryProvider := provider.NewMemory()
+	if _, err := memoryProvider.Save(ctx, "test.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := ReadOnly(memoryProvider)
+
+	actual, err := s.Get(ctx, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]byte("test"), actual); d != "" {
+		t.Fatalf("unexpected contents. %s", d)
+	}
+
+	var roErr *ReadOnlyError
+
+	if _, err := s.Save(ctx, "test.txt", []byte("overwrite")); !errors.As(err, &roErr) {
+		t.Fatalf("Save should fail with ReadOnlyError. %v", err)
+	}
+
+	if err := s.Delete(ctx, "test.txt"); !errors.As(err, &roErr) || roErr.Op != "delete" {
+		t.Fatalf("Delete should fail with ReadOnlyError. %v", err)
+	}
+
+	if err := s.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := s.(Lister).List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 1 {
+		t.Fatalf("unexpected files. %+v", infos)
+	}
+
+	if _, err := s.(Watcher).Watch(ctx, ""); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Watch should fail with ErrNotSupported. %v", err)
+	}
+}
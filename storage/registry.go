@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+// Factory builds a Storage from Config.
+// Provider specific settings can be read from Config.Options by Config.DecodeOptions.
+type Factory func(serviceName string, conf *Config) (Storage, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[StorageType]Factory{}
+)
+
+func init() {
+	Register(StorageTypeDisk, func(serviceName string, conf *Config) (Storage, error) {
+		return provider.NewDisk(conf.Disk.RootDir), nil
+	})
+	Register(StorageTypeS3, func(serviceName string, conf *Config) (Storage, error) {
+		return provider.NewS3(conf.S3.BucketName, serviceName, conf.S3.Region)
+	})
+	Register(StorageTypeSFTP, func(serviceName string, conf *Config) (Storage, error) {
+		return newSFTP(conf)
+	})
+	Register(StorageTypeWebDAV, func(serviceName string, conf *Config) (Storage, error) {
+		return provider.NewWebDAV(provider.WebDAVConfig{
+			URL:         conf.WebDAV.URL,
+			Username:    conf.WebDAV.Username,
+			Password:    conf.WebDAV.Password,
+			BearerToken: conf.WebDAV.BearerToken,
+		})
+	})
+}
+
+// newSFTP builds provider.SFTP authenticating by the private key and verifying the host key by the known_hosts file.
+func newSFTP(conf *Config) (*provider.SFTP, error) {
+	key, err := os.ReadFile(conf.SFTP.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the private key. %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key. %w", err)
+	}
+
+	hostKeyCallback, err := knownhosts.New(conf.SFTP.KnownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the known hosts. %w", err)
+	}
+
+	return provider.NewSFTP(provider.SFTPConfig{
+		Addr:            conf.SFTP.Addr,
+		User:            conf.SFTP.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		RootDir:         conf.SFTP.RootDir,
+		MaxConns:        conf.SFTP.MaxConns,
+	})
+}
+
+// Register makes a Storage available by NewStorage for Config with the type.
+// It panics when the type is already registered.
+func Register(storageType StorageType, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, ok := factories[storageType]; ok {
+		panic(fmt.Sprintf("storage: factory for %s is already registered", storageType))
+	}
+
+	factories[storageType] = factory
+}
+
+func lookupFactory(storageType StorageType) (Factory, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	factory, ok := factories[storageType]
+
+	return factory, ok
+}
+
+func registeredTypes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	types := make([]string, 0, len(factories))
+	for t := range factories {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+
+	return types
+}
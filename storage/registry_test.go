@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+type testRegistryOptions struct {
+	Endpoint string        `envconfig:"ENDPOINT"`
+	Retry    int           `envconfig:"RETRY" default:"3"`
+	Timeout  time.Duration `envconfig:"TIMEOUT"`
+	Secure   bool
+}
+
+func TestRegister(t *testing.T) {
+	var decoded testRegistryOptions
+
+	Register("test-registry", func(serviceName string, conf *Config) (Storage, error) {
+		if err := conf.DecodeOptions(&decoded); err != nil {
+			return nil, err
+		}
+
+		return provider.NewMemory(), nil
+	})
+
+	t.Setenv("TYPE", "test-registry")
+	t.Setenv("OPTIONS", "endpoint:http://localhost:8080,timeout:5s,secure:true")
+
+	conf, err := LoadConfigFromEnv("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStorage("test", conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.(*provider.Memory); !ok {
+		t.Fatalf("unexpected storage. %T", s)
+	}
+
+	expected := testRegistryOptions{
+		Endpoint: "http://localhost:8080",
+		Retry:    3,
+		Timeout:  5 * time.Second,
+		Secure:   true,
+	}
+	if decoded != expected {
+		t.Fatalf("unexpected options. %+v", decoded)
+	}
+
+	conf.Options["retry"] = "many"
+	if _, err := NewStorage("test", conf); err == nil {
+		t.Fatal("invalid option should fail")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a type twice should panic")
+		}
+	}()
+	Register(StorageTypeDisk, nil)
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// Stater is implemented by storages that can return the information of an object without its data.
+// Stat returns nil when the object does not exist.
+type Stater interface {
+	Stat(ctx context.Context, filePath string) (*object.Info, error)
+}
+
+func stat(ctx context.Context, s Storage, filePath string) (*object.Info, error) {
+	st, ok := s.(Stater)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support stat: %w", s, ErrNotSupported)
+	}
+
+	return st.Stat(ctx, filePath)
+}
+
+// copyOptions returns the options to save a copy of the object with its content type and content disposition.
+// It returns no options when s does not implement Stater or the object does not exist.
+func copyOptions(ctx context.Context, s Storage, filePath string) ([]option.SaveOptionFunc, error) {
+	st, ok := s.(Stater)
+	if !ok {
+		return nil, nil
+	}
+
+	info, err := st.Stat(ctx, filePath)
+	if err != nil || info == nil {
+		return nil, err
+	}
+
+	var opts []option.SaveOptionFunc
+	if info.ContentType != "" {
+		opts = append(opts, option.SaveOptionWithContentType(info.ContentType))
+	}
+	if info.ContentDisposition != "" {
+		opts = append(opts, option.SaveOptionWithContentDisposition(info.ContentDisposition))
+	}
+
+	return opts, nil
+}
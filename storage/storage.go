@@ -8,6 +8,9 @@ import (
 	"github.com/hatappi/go-kit/storage/provider"
 )
 
+// ErrNotSupported is returned when a storage does not implement an optional interface such as Lister.
+var ErrNotSupported = provider.ErrNotSupported
+
 type Storage interface {
 	Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error)
 	Get(ctx context.Context, filePath string) ([]byte, error)
@@ -16,12 +19,14 @@ type Storage interface {
 }
 
 func NewStorage(serviceName string, conf *Config) (Storage, error) {
-	switch conf.Type {
-	case StorageTypeDisk:
-		return provider.NewDisk(conf.Disk.RootDir), nil
-	case StorageTypeS3:
-		return provider.NewS3(conf.S3.BucketName, serviceName, conf.S3.Region)
-	default:
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	factory, ok := lookupFactory(conf.Type)
+	if !ok {
 		return nil, fmt.Errorf("invalid storage type: %s", conf.Type)
 	}
+
+	return factory(serviceName, conf)
 }
@@ -0,0 +1,285 @@
+// Package storagetest provides utilities for testing code using storage.Storage.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// ErrInjected is returned by Faulty for the errors injected by FaultyOptionWithErrorRate.
+var ErrInjected = errors.New("injected fault")
+
+var operations = []storage.Operation{
+	storage.OperationSave,
+	storage.OperationGet,
+	storage.OperationGetRange,
+	storage.OperationDelete,
+	storage.OperationStat,
+	storage.OperationList,
+	storage.OperationPing,
+}
+
+type latency struct {
+	min, max time.Duration
+}
+
+type keyError struct {
+	pattern string
+	err     error
+	ops     map[storage.Operation]bool
+}
+
+// Faulty wraps a Storage and injects latency, errors and partial reads into its operations.
+// Faults are drawn from a pseudo-random source, so a sequence of operations with the same seed gets the same faults.
+// Concurrent operations draw in the order they arrive.
+type Faulty struct {
+	storage storage.Storage
+
+	latencies   map[storage.Operation]latency
+	errorRates  map[storage.Operation]float64
+	partialRate float64
+	keyErrors   []keyError
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+type FaultyOptionFunc func(f *Faulty)
+
+// FaultyOptionWithSeed sets the seed of the faults. It defaults to the current time.
+func FaultyOptionWithSeed(seed int64) FaultyOptionFunc {
+	return func(f *Faulty) {
+		f.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// FaultyOptionWithLatency delays the operations, or all operations when ops is empty, by a random duration between minDelay and maxDelay.
+func FaultyOptionWithLatency(minDelay, maxDelay time.Duration, ops ...storage.Operation) FaultyOptionFunc {
+	return func(f *Faulty) {
+		for _, op := range orAll(ops) {
+			f.latencies[op] = latency{min: minDelay, max: maxDelay}
+		}
+	}
+}
+
+// FaultyOptionWithErrorRate fails the operations, or all operations when ops is empty, with ErrInjected at the rate between 0 and 1.
+func FaultyOptionWithErrorRate(rate float64, ops ...storage.Operation) FaultyOptionFunc {
+	return func(f *Faulty) {
+		for _, op := range orAll(ops) {
+			f.errorRates[op] = rate
+		}
+	}
+}
+
+// FaultyOptionWithPartialReadRate truncates the data of Get and GetRange at the rate between 0 and 1,
+// returning the data read so far with io.ErrUnexpectedEOF like a connection closed in the middle of a response.
+func FaultyOptionWithPartialReadRate(rate float64) FaultyOptionFunc {
+	return func(f *Faulty) {
+		f.partialRate = rate
+	}
+}
+
+// FaultyOptionWithKeyError always fails the operations, or all operations when ops is empty, on keys matching pattern with err.
+// pattern has the syntax of path.Match, and the prefix is matched for List.
+func FaultyOptionWithKeyError(pattern string, err error, ops ...storage.Operation) FaultyOptionFunc {
+	return func(f *Faulty) {
+		ke := keyError{pattern: pattern, err: err, ops: map[storage.Operation]bool{}}
+		for _, op := range orAll(ops) {
+			ke.ops[op] = true
+		}
+
+		f.keyErrors = append(f.keyErrors, ke)
+	}
+}
+
+func orAll(ops []storage.Operation) []storage.Operation {
+	if len(ops) == 0 {
+		return operations
+	}
+
+	return ops
+}
+
+func NewFaulty(s storage.Storage, opts ...FaultyOptionFunc) *Faulty {
+	f := &Faulty{
+		storage:    s,
+		latencies:  map[storage.Operation]latency{},
+		errorRates: map[storage.Operation]float64{},
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+func (f *Faulty) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	if err := f.inject(ctx, storage.OperationSave, filePath); err != nil {
+		return "", err
+	}
+
+	return f.storage.Save(ctx, filePath, data, opts...)
+}
+
+func (f *Faulty) Get(ctx context.Context, filePath string) ([]byte, error) {
+	if err := f.inject(ctx, storage.OperationGet, filePath); err != nil {
+		return nil, err
+	}
+
+	data, err := f.storage.Get(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.truncate(data)
+}
+
+func (f *Faulty) Delete(ctx context.Context, filePath string) error {
+	if err := f.inject(ctx, storage.OperationDelete, filePath); err != nil {
+		return err
+	}
+
+	return f.storage.Delete(ctx, filePath)
+}
+
+func (f *Faulty) Ping(ctx context.Context) error {
+	if err := f.inject(ctx, storage.OperationPing, ""); err != nil {
+		return err
+	}
+
+	return f.storage.Ping(ctx)
+}
+
+func (f *Faulty) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	rg, ok := f.storage.(storage.RangeGetter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support range reads: %w", f.storage, storage.ErrNotSupported)
+	}
+
+	if err := f.inject(ctx, storage.OperationGetRange, filePath); err != nil {
+		return nil, err
+	}
+
+	data, err := rg.GetRange(ctx, filePath, offset, length)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.truncate(data)
+}
+
+func (f *Faulty) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	st, ok := f.storage.(storage.Stater)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support stat: %w", f.storage, storage.ErrNotSupported)
+	}
+
+	if err := f.inject(ctx, storage.OperationStat, filePath); err != nil {
+		return nil, err
+	}
+
+	return st.Stat(ctx, filePath)
+}
+
+func (f *Faulty) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	l, ok := f.storage.(storage.Lister)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support listing: %w", f.storage, storage.ErrNotSupported)
+	}
+
+	if err := f.inject(ctx, storage.OperationList, prefix); err != nil {
+		return nil, err
+	}
+
+	return l.List(ctx, prefix)
+}
+
+// inject waits for the latency of op and returns the error to inject, if any.
+func (f *Faulty) inject(ctx context.Context, op storage.Operation, key string) error {
+	f.mu.Lock()
+	delay := f.delay(op)
+	fail := f.draw(f.errorRates[op])
+	f.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	for _, ke := range f.keyErrors {
+		if !ke.ops[op] {
+			continue
+		}
+
+		if ok, _ := path.Match(ke.pattern, key); ok {
+			return ke.err
+		}
+	}
+
+	if fail {
+		return fmt.Errorf("%s %s: %w", op, key, ErrInjected)
+	}
+
+	return nil
+}
+
+func (f *Faulty) truncate(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	f.mu.Lock()
+	partial := f.draw(f.partialRate)
+	n := 0
+	if partial {
+		n = f.rand.Intn(len(data))
+	}
+	f.mu.Unlock()
+
+	if !partial {
+		return data, nil
+	}
+
+	return data[:n], io.ErrUnexpectedEOF
+}
+
+// delay must be called with f.mu held.
+func (f *Faulty) delay(op storage.Operation) time.Duration {
+	l, ok := f.latencies[op]
+	if !ok || l.max <= 0 {
+		return 0
+	}
+
+	if l.max <= l.min {
+		return l.min
+	}
+
+	return l.min + time.Duration(f.rand.Int63n(int64(l.max-l.min)+1))
+}
+
+// draw must be called with f.mu held.
+func (f *Faulty) draw(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	return f.rand.Float64() < rate
+}
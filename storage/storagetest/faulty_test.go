@@ -0,0 +1,115 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestFaultyErrorRate(t *testing.T) {
+	ctx := context.Background()
+
+	run := func() []bool {
+		f := NewFaulty(provider.NewMemory(), FaultyOptionWithSeed(1), FaultyOptionWithErrorRate(0.5, storage.OperationSave))
+
+		var failed []bool
+		for i := 0; i < 20; i++ {
+			_, err := f.Save(ctx, "test.txt", []byte("test"))
+			if err != nil && !errors.Is(err, ErrInjected) {
+				t.Fatal(err)
+			}
+
+			failed = append(failed, err != nil)
+		}
+
+		if _, err := f.Get(ctx, "test.txt"); err != nil {
+			t.Errorf("Get should not fail. %v", err)
+		}
+
+		return failed
+	}
+
+	first := run()
+	if d := cmp.Diff(first, run()); d != "" {
+		t.Errorf("faults should be deterministic with the same seed. %s", d)
+	}
+
+	var count int
+	for _, failed := range first {
+		if failed {
+			count++
+		}
+	}
+	if count == 0 || count == len(first) {
+		t.Errorf("about half of the saves should fail. %v", first)
+	}
+}
+
+func TestFaultyKeyError(t *testing.T) {
+	ctx := context.Background()
+
+	errDenied := errors.New("denied")
+	f := NewFaulty(provider.NewMemory(), FaultyOptionWithKeyError("secret/*", errDenied, storage.OperationSave, storage.OperationGet))
+
+	testCases := []struct {
+		filePath string
+		wantErr  error
+	}{
+		{filePath: "secret/a.txt", wantErr: errDenied},
+		{filePath: "public/a.txt"},
+		{filePath: "secret/dir/a.txt"},
+	}
+
+	for _, tc := range testCases {
+		if _, err := f.Save(ctx, tc.filePath, []byte("test")); !errors.Is(err, tc.wantErr) {
+			t.Errorf("unexpected error on saving %s. %v", tc.filePath, err)
+		}
+	}
+
+	if err := f.Delete(ctx, "secret/a.txt"); err != nil {
+		t.Errorf("Delete should not fail. %v", err)
+	}
+}
+
+func TestFaultyPartialRead(t *testing.T) {
+	ctx := context.Background()
+
+	f := NewFaulty(provider.NewMemory(), FaultyOptionWithSeed(1), FaultyOptionWithPartialReadRate(1))
+	if _, err := f.Save(ctx, "test.txt", []byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := f.Get(ctx, "test.txt")
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Get should fail with io.ErrUnexpectedEOF. %v", err)
+	}
+	if len(data) >= 10 || string(data) != "0123456789"[:len(data)] {
+		t.Errorf("unexpected partial data. %s", data)
+	}
+}
+
+func TestFaultyLatency(t *testing.T) {
+	f := NewFaulty(provider.NewMemory(), FaultyOptionWithLatency(time.Hour, time.Hour, storage.OperationPing))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := f.Ping(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Ping should wait for the latency until ctx is done. %v", err)
+	}
+
+	start := time.Now()
+	if _, err := f.Get(context.Background(), "test.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Get should not be delayed. %s", elapsed)
+	}
+}
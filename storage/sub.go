@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// ErrOutsidePrefix is returned when a path of Sub escapes its prefix, e.g. ../other.
+var ErrOutsidePrefix = errors.New("path is outside of the prefix")
+
+type sub struct {
+	storage Storage
+	prefix  string
+}
+
+// Sub wraps s so that all paths are under prefix.
+// Paths given to the returned storage and keys returned from it are relative to prefix.
+func Sub(s Storage, prefix string) Storage {
+	return &sub{
+		storage: s,
+		prefix:  strings.Trim(path.Clean("/"+prefix), "/"),
+	}
+}
+
+func (s *sub) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	p, err := s.fullPath(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return s.storage.Save(ctx, p, data, opts...)
+}
+
+func (s *sub) Get(ctx context.Context, filePath string) ([]byte, error) {
+	p, err := s.fullPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.storage.Get(ctx, p)
+}
+
+func (s *sub) Delete(ctx context.Context, filePath string) error {
+	p, err := s.fullPath(filePath)
+	if err != nil {
+		return err
+	}
+
+	return s.storage.Delete(ctx, p)
+}
+
+func (s *sub) Ping(ctx context.Context) error {
+	return s.storage.Ping(ctx)
+}
+
+func (s *sub) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	rg, ok := s.storage.(RangeGetter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support range reads: %w", s.storage, ErrNotSupported)
+	}
+
+	p, err := s.fullPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return rg.GetRange(ctx, p, offset, length)
+}
+
+func (s *sub) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	p, err := s.fullPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := stat(ctx, s.storage, p)
+	if err != nil || info == nil {
+		return info, err
+	}
+
+	info.Key = s.relPath(info.Key)
+
+	return info, nil
+}
+
+func (s *sub) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	p, err := s.fullPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := list(ctx, s.storage, p)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range infos {
+		infos[i].Key = s.relPath(infos[i].Key)
+	}
+
+	return infos, nil
+}
+
+func (s *sub) Watch(ctx context.Context, prefix string) (<-chan object.Event, error) {
+	w, ok := s.storage.(Watcher)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support watching: %w", s.storage, ErrNotSupported)
+	}
+
+	p, err := s.fullPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := w.Watch(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan object.Event)
+
+	go func() {
+		defer close(ch)
+
+		for event := range events {
+			event.Info.Key = s.relPath(event.Info.Key)
+
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- event:
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (s *sub) fullPath(filePath string) (string, error) {
+	clean := path.Clean(filePath)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%s: %w", filePath, ErrOutsidePrefix)
+	}
+
+	return path.Join(s.prefix, clean), nil
+}
+
+// fullPrefix keeps the trailing slash of prefix, which path.Join drops.
+func (s *sub) fullPrefix(prefix string) (string, error) {
+	if prefix == "" {
+		if s.prefix == "" {
+			return "", nil
+		}
+
+		return s.prefix + "/", nil
+	}
+
+	p, err := s.fullPath(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasSuffix(prefix, "/") {
+		p += "/"
+	}
+
+	return p, nil
+}
+
+func (s *sub) relPath(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+
+	return strings.TrimPrefix(key, s.prefix+"/")
+}
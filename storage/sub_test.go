@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestSub(t *testing.T) {
+	ctx := context.Background()
+
+	memoryProvider := provider.NewMemory()
+	if _, err := memoryProvider.Save(ctx, "other/test.txt", []byte("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	s := Sub(memoryProvider, "/tenant/")
+
+	if _, err := s.Save(ctx, "a/test.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := memoryProvider.Get(ctx, "tenant/a/test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]byte("test"), actual); d != "" {
+		t.Fatalf("file was not saved under the prefix. %s", d)
+	}
+
+	actual, err = s.(RangeGetter).GetRange(ctx, "a/test.txt", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]byte("es"), actual); d != "" {
+		t.Fatalf("unexpected contents. %s", d)
+	}
+
+	infos, err := s.(Lister).List(ctx, "a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 1 || infos[0].Key != "a/test.txt" {
+		t.Fatalf("unexpected files. %+v", infos)
+	}
+
+	for _, p := range []string{"../other/test.txt", "a/../../other/test.txt", ".."} {
+		if _, err := s.Get(ctx, p); !errors.Is(err, ErrOutsidePrefix) {
+			t.Fatalf("%s should be rejected. %v", p, err)
+		}
+	}
+
+	if err := s.Delete(ctx, "a/test.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err = memoryProvider.Get(ctx, "tenant/a/test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual != nil {
+		t.Fatal("file was not deleted")
+	}
+}
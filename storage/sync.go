@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+const defaultSyncConcurrency = 8
+
+// SyncResult reports keys handled by Sync.
+type SyncResult struct {
+	Copied    []string
+	Deleted   []string
+	Unchanged []string
+}
+
+// Sync copies objects under prefix from src to dst when they are missing or changed in dst.
+// Both storages must implement Lister.
+func Sync(ctx context.Context, src, dst Storage, prefix string, opts ...option.SyncOptionFunc) (*SyncResult, error) {
+	syncOpt := option.SyncOption{
+		Comparison:  option.SyncCompareChecksum,
+		Concurrency: defaultSyncConcurrency,
+	}
+	for _, opt := range opts {
+		opt(&syncOpt)
+	}
+
+	srcInfos, err := list(ctx, src, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source objects. %w", err)
+	}
+
+	dstInfos, err := list(ctx, dst, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination objects. %w", err)
+	}
+
+	dstByKey := make(map[string]object.Info, len(dstInfos))
+	for _, info := range dstInfos {
+		dstByKey[info.Key] = info
+	}
+
+	result := &SyncResult{}
+	var mu sync.Mutex
+	var errs []error
+
+	pool := newWorkerPool(ctx, syncOpt.Concurrency)
+
+	for _, info := range srcInfos {
+		info := info
+
+		pool.Go(func(ctx context.Context) {
+			d, exists := dstByKey[info.Key]
+
+			changed := !exists
+			if exists {
+				var err error
+				changed, err = syncChanged(ctx, src, dst, info, d, syncOpt.Comparison)
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("failed to compare %s. %w", info.Key, err))
+					mu.Unlock()
+
+					return
+				}
+			}
+
+			if !changed {
+				mu.Lock()
+				result.Unchanged = append(result.Unchanged, info.Key)
+				mu.Unlock()
+
+				return
+			}
+
+			if !syncOpt.DryRun {
+				if err := copyObject(ctx, src, dst, info.Key); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("failed to copy %s. %w", info.Key, err))
+					mu.Unlock()
+
+					return
+				}
+			}
+
+			mu.Lock()
+			result.Copied = append(result.Copied, info.Key)
+			mu.Unlock()
+		})
+	}
+
+	if syncOpt.DeleteExtraneous {
+		srcKeys := make(map[string]struct{}, len(srcInfos))
+		for _, info := range srcInfos {
+			srcKeys[info.Key] = struct{}{}
+		}
+
+		for _, info := range dstInfos {
+			if _, ok := srcKeys[info.Key]; ok {
+				continue
+			}
+
+			key := info.Key
+
+			pool.Go(func(ctx context.Context) {
+				if !syncOpt.DryRun {
+					if err := dst.Delete(ctx, key); err != nil {
+						mu.Lock()
+						errs = append(errs, fmt.Errorf("failed to delete %s. %w", key, err))
+						mu.Unlock()
+
+						return
+					}
+				}
+
+				mu.Lock()
+				result.Deleted = append(result.Deleted, key)
+				mu.Unlock()
+			})
+		}
+	}
+
+	if err := pool.Wait(); err != nil {
+		errs = append(errs, err)
+	}
+
+	sort.Strings(result.Copied)
+	sort.Strings(result.Deleted)
+	sort.Strings(result.Unchanged)
+
+	return result, joinErrors(errs...)
+}
+
+func syncChanged(ctx context.Context, src, dst Storage, srcInfo, dstInfo object.Info, c option.SyncComparison) (bool, error) {
+	if srcInfo.Size != dstInfo.Size {
+		return true, nil
+	}
+
+	switch c {
+	case option.SyncCompareSize:
+		return false, nil
+	case option.SyncCompareModTime:
+		return srcInfo.LastModified.After(dstInfo.LastModified), nil
+	default:
+		srcSum, err := checksum(ctx, src, srcInfo)
+		if err != nil {
+			return false, err
+		}
+
+		dstSum, err := checksum(ctx, dst, dstInfo)
+		if err != nil {
+			return false, err
+		}
+
+		return srcSum != dstSum, nil
+	}
+}
+
+// checksum returns the hex encoded MD5 of the object.
+// The ETag is used when it is an MD5, which is the case for objects not uploaded by multipart upload.
+func checksum(ctx context.Context, s Storage, info object.Info) (string, error) {
+	etag := strings.Trim(info.ETag, `"`)
+	if len(etag) == md5.Size*2 {
+		if _, err := hex.DecodeString(etag); err == nil {
+			return strings.ToLower(etag), nil
+		}
+	}
+
+	data, err := s.Get(ctx, info.Key)
+	if err != nil {
+		return "", err
+	}
+
+	sum := md5.Sum(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func copyObject(ctx context.Context, src, dst Storage, key string) error {
+	data, err := src.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		return errors.New("file does not exist")
+	}
+
+	opts, err := copyOptions(ctx, src, key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := dst.Save(ctx, key, data, opts...); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/option"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestSync(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []option.SyncOptionFunc
+
+		wantResult *SyncResult
+		wantDst    map[string]string
+	}{
+		{
+			name: "copy changed objects",
+			wantResult: &SyncResult{
+				Copied:    []string{"data/changed.txt", "data/new.txt"},
+				Unchanged: []string{"data/same.txt"},
+			},
+			wantDst: map[string]string{
+				"data/changed.txt": "new",
+				"data/new.txt":     "new",
+				"data/same.txt":    "same",
+				"data/extra.txt":   "extra",
+				"other.txt":        "other",
+			},
+		},
+		{
+			name: "delete extraneous objects",
+			opts: []option.SyncOptionFunc{option.SyncOptionWithDeleteExtraneous()},
+			wantResult: &SyncResult{
+				Copied:    []string{"data/changed.txt", "data/new.txt"},
+				Deleted:   []string{"data/extra.txt"},
+				Unchanged: []string{"data/same.txt"},
+			},
+			wantDst: map[string]string{
+				"data/changed.txt": "new",
+				"data/new.txt":     "new",
+				"data/same.txt":    "same",
+				"other.txt":        "other",
+			},
+		},
+		{
+			name: "compare by size",
+			opts: []option.SyncOptionFunc{option.SyncOptionWithComparison(option.SyncCompareSize)},
+			wantResult: &SyncResult{
+				Copied:    []string{"data/new.txt"},
+				Unchanged: []string{"data/changed.txt", "data/same.txt"},
+			},
+			wantDst: map[string]string{
+				"data/changed.txt": "old",
+				"data/new.txt":     "new",
+				"data/same.txt":    "same",
+				"data/extra.txt":   "extra",
+				"other.txt":        "other",
+			},
+		},
+		{
+			name: "dry run",
+			opts: []option.SyncOptionFunc{option.SyncOptionWithDryRun(), option.SyncOptionWithDeleteExtraneous()},
+			wantResult: &SyncResult{
+				Copied:    []string{"data/changed.txt", "data/new.txt"},
+				Deleted:   []string{"data/extra.txt"},
+				Unchanged: []string{"data/same.txt"},
+			},
+			wantDst: map[string]string{
+				"data/changed.txt": "old",
+				"data/same.txt":    "same",
+				"data/extra.txt":   "extra",
+				"other.txt":        "other",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			src := newTestDisk(t, map[string]string{
+				"data/changed.txt": "new",
+				"data/new.txt":     "new",
+				"data/same.txt":    "same",
+			})
+			dst := newTestDisk(t, map[string]string{
+				"data/changed.txt": "old",
+				"data/same.txt":    "same",
+				"data/extra.txt":   "extra",
+				"other.txt":        "other",
+			})
+
+			result, err := Sync(ctx, src, dst, "data/", tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if d := cmp.Diff(tc.wantResult, result); d != "" {
+				t.Fatalf("unexpected result. %s", d)
+			}
+
+			if d := cmp.Diff(tc.wantDst, readAll(t, dst)); d != "" {
+				t.Fatalf("unexpected destination. %s", d)
+			}
+		})
+	}
+}
+
+func newTestDisk(t *testing.T, files map[string]string) *provider.Disk {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	d := provider.NewDisk(dir)
+	for p, content := range files {
+		if _, err := d.Save(context.Background(), p, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return d
+}
+
+func readAll(t *testing.T, d *provider.Disk) map[string]string {
+	t.Helper()
+
+	ctx := context.Background()
+
+	infos, err := d.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	for _, info := range infos {
+		b, err := d.Get(ctx, info.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files[info.Key] = string(b)
+	}
+
+	return files
+}
+
+func TestSyncKeepsMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	src := newTestDisk(t, nil)
+	_, err := src.Save(ctx, "data/report.csv", []byte("a,b"),
+		option.SaveOptionWithContentType("text/csv"),
+		option.SaveOptionWithContentDisposition(`attachment; filename="report.csv"`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := provider.NewMemory()
+	if _, err := Sync(ctx, src, dst, "data/"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := dst.Stat(ctx, "data/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info == nil || info.ContentType != "text/csv" || info.ContentDisposition != `attachment; filename="report.csv"` {
+		t.Errorf("metadata should be copied. %+v", info)
+	}
+}
@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hatappi/go-kit/storage/contenttype"
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+const defaultTransferConcurrency = 8
+
+// TransferResult reports files handled by UploadDir and DownloadPrefix.
+type TransferResult struct {
+	Transferred []string
+	Skipped     []string
+	Bytes       int64
+}
+
+// TransferError aggregates the files which failed to be transferred.
+type TransferError struct {
+	Errors map[string]error
+}
+
+func (e *TransferError) Error() string {
+	keys := make([]string, 0, len(e.Errors))
+	for k := range e.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	msgs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", k, e.Errors[k]))
+	}
+
+	return fmt.Sprintf("failed to transfer %d files. %s", len(keys), strings.Join(msgs, ", "))
+}
+
+type transferFile struct {
+	key       string
+	localPath string
+	size      int64
+}
+
+// UploadDir saves all files under localDir to s under prefix with their detected content type.
+func UploadDir(ctx context.Context, s Storage, localDir, prefix string, opts ...option.TransferOptionFunc) (*TransferResult, error) {
+	transferOpt := newTransferOption(opts)
+
+	var files []transferFile
+	err := filepath.Walk(localDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, transferFile{
+			key:       path.Join(prefix, filepath.ToSlash(rel)),
+			localPath: p,
+			size:      info.Size(),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]int64{}
+	if transferOpt.Resume {
+		if l, ok := s.(Lister); ok {
+			infos, err := l.List(ctx, prefix)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, info := range infos {
+				existing[info.Key] = info.Size
+			}
+		}
+	}
+
+	return transfer(ctx, files, transferOpt, func(f transferFile) bool {
+		size, ok := existing[f.key]
+		return ok && size == f.size
+	}, func(ctx context.Context, f transferFile) (int64, error) {
+		data, err := os.ReadFile(f.localPath)
+		if err != nil {
+			return 0, err
+		}
+
+		ct := contenttype.Detect(f.localPath, data)
+		if _, err := s.Save(ctx, f.key, data, option.SaveOptionWithContentType(ct)); err != nil {
+			return 0, err
+		}
+
+		return int64(len(data)), nil
+	})
+}
+
+// DownloadPrefix writes all objects of s under prefix into localDir. s must implement Lister.
+// An object whose key equals prefix is written as its base name, and keys which would be written outside of localDir
+// are reported as errors.
+func DownloadPrefix(ctx context.Context, s Storage, prefix, localDir string, opts ...option.TransferOptionFunc) (*TransferResult, error) {
+	transferOpt := newTransferOption(opts)
+
+	infos, err := list(ctx, s, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]transferFile, 0, len(infos))
+	for _, info := range infos {
+		files = append(files, downloadFile(info, prefix, localDir))
+	}
+
+	return transfer(ctx, files, transferOpt, func(f transferFile) bool {
+		fi, err := os.Stat(f.localPath)
+		return err == nil && fi.Size() == f.size
+	}, func(ctx context.Context, f transferFile) (int64, error) {
+		if f.localPath == "" {
+			return 0, fmt.Errorf("%s is outside of %s", f.key, localDir)
+		}
+
+		data, err := s.Get(ctx, f.key)
+		if err != nil {
+			return 0, err
+		}
+
+		if data == nil {
+			return 0, fmt.Errorf("%s does not exist", f.key)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(f.localPath), 0755); err != nil {
+			return 0, err
+		}
+
+		// write to a temporary file first so that an interrupted download is not taken as complete on resume
+		tmp, err := os.CreateTemp(filepath.Dir(f.localPath), "."+filepath.Base(f.localPath)+".*")
+		if err != nil {
+			return 0, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if err := tmp.Chmod(0644); err != nil {
+			return 0, err
+		}
+
+		if _, err := tmp.Write(data); err != nil {
+			return 0, err
+		}
+
+		if err := tmp.Close(); err != nil {
+			return 0, err
+		}
+
+		if err := os.Rename(tmp.Name(), f.localPath); err != nil {
+			return 0, err
+		}
+
+		return int64(len(data)), nil
+	})
+}
+
+func downloadFile(info object.Info, prefix, localDir string) transferFile {
+	f := transferFile{
+		key:  info.Key,
+		size: info.Size,
+	}
+
+	rel := strings.TrimPrefix(strings.TrimPrefix(info.Key, prefix), "/")
+	if rel == "" {
+		// prefix is the key itself
+		rel = path.Base(info.Key)
+	}
+	localPath := filepath.Join(localDir, filepath.FromSlash(rel))
+
+	if r, err := filepath.Rel(localDir, localPath); err == nil && r != "." && r != ".." && !strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		f.localPath = localPath
+	}
+
+	return f
+}
+
+func newTransferOption(opts []option.TransferOptionFunc) option.TransferOption {
+	transferOpt := option.TransferOption{
+		Concurrency: defaultTransferConcurrency,
+	}
+	for _, opt := range opts {
+		opt(&transferOpt)
+	}
+
+	return transferOpt
+}
+
+func transfer(
+	ctx context.Context,
+	files []transferFile,
+	transferOpt option.TransferOption,
+	skip func(transferFile) bool,
+	do func(context.Context, transferFile) (int64, error),
+) (*TransferResult, error) {
+	result := &TransferResult{}
+	errs := map[string]error{}
+
+	var mu sync.Mutex
+	done := 0
+
+	report := func(p option.TransferProgress) {
+		mu.Lock()
+		done++
+		p.Done, p.Total = done, len(files)
+		mu.Unlock()
+
+		if transferOpt.Progress != nil {
+			transferOpt.Progress(p)
+		}
+	}
+
+	pool := newWorkerPool(ctx, transferOpt.Concurrency)
+
+	for _, f := range files {
+		f := f
+
+		if transferOpt.Resume && skip(f) {
+			result.Skipped = append(result.Skipped, f.key)
+			report(option.TransferProgress{Key: f.key})
+			continue
+		}
+
+		pool.Go(func(ctx context.Context) {
+			n, err := do(ctx, f)
+
+			mu.Lock()
+			if err != nil {
+				errs[f.key] = err
+			} else {
+				result.Transferred = append(result.Transferred, f.key)
+				result.Bytes += n
+			}
+			mu.Unlock()
+
+			report(option.TransferProgress{Key: f.key, Bytes: n, Err: err})
+		})
+	}
+
+	if err := pool.Wait(); err != nil {
+		return result, err
+	}
+
+	sort.Strings(result.Transferred)
+	sort.Strings(result.Skipped)
+
+	if len(errs) > 0 {
+		return result, &TransferError{Errors: errs}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/option"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestUploadDir(t *testing.T) {
+	dir := t.TempDir()
+	for p, content := range map[string]string{"a.txt": "a", "sub/b.html": "<html></html>"} {
+		fullPath := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	memoryProvider := provider.NewMemory()
+
+	var progress int64
+	result, err := UploadDir(ctx, memoryProvider, dir, "up", option.TransferOptionWithProgress(func(p option.TransferProgress) {
+		atomic.AddInt64(&progress, 1)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]string{"up/a.txt", "up/sub/b.html"}, result.Transferred); d != "" {
+		t.Fatalf("unexpected transferred files. %s", d)
+	}
+
+	if progress != 2 {
+		t.Fatalf("progress was reported %d times", progress)
+	}
+
+	result, err = UploadDir(ctx, memoryProvider, dir, "up", option.TransferOptionWithResume())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Transferred) != 0 || len(result.Skipped) != 2 {
+		t.Fatalf("uploaded files should be skipped on resume. %+v", result)
+	}
+}
+
+func TestDownloadPrefix(t *testing.T) {
+	ctx := context.Background()
+
+	memoryProvider := provider.NewMemory()
+	for p, content := range map[string]string{"down/a.txt": "a", "down/sub/b.txt": "b", "down/../../evil": "evil"} {
+		if _, err := memoryProvider.Save(ctx, p, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "out")
+
+	result, err := DownloadPrefix(ctx, memoryProvider, "down/", dir)
+
+	var transferErr *TransferError
+	if !errors.As(err, &transferErr) || len(transferErr.Errors) != 1 || transferErr.Errors["down/../../evil"] == nil {
+		t.Fatalf("key escaping the directory should be reported. %v", err)
+	}
+
+	if d := cmp.Diff([]string{"down/a.txt", "down/sub/b.txt"}, result.Transferred); d != "" {
+		t.Fatalf("unexpected transferred files. %s", d)
+	}
+
+	actual, err := os.ReadFile(filepath.Join(dir, "sub", "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(actual) != "b" {
+		t.Fatalf("unexpected contents. %s", actual)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if d := cmp.Diff([]string{"a.txt", "sub"}, names); d != "" {
+		t.Fatalf("temporary files should not be left. %s", d)
+	}
+}
+
+func TestDownloadPrefixExactKey(t *testing.T) {
+	ctx := context.Background()
+
+	memoryProvider := provider.NewMemory()
+	if _, err := memoryProvider.Save(ctx, "down/a.txt", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+
+	result, err := DownloadPrefix(ctx, memoryProvider, "down/a.txt", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]string{"down/a.txt"}, result.Transferred); d != "" {
+		t.Fatalf("unexpected transferred files. %s", d)
+	}
+
+	actual, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(actual) != "a" {
+		t.Fatalf("unexpected contents. %s", actual)
+	}
+
+	result, err = DownloadPrefix(ctx, memoryProvider, "down/a.txt", dir, option.TransferOptionWithResume())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := cmp.Diff([]string{"down/a.txt"}, result.Skipped); d != "" {
+		t.Fatalf("downloaded file should be skipped on resume. %s", d)
+	}
+}
@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+const (
+	defaultTrashPrefix    = ".trash"
+	defaultTrashRetention = 30 * 24 * time.Hour
+
+	// trashTimeLayout formats deletion times in UTC with a fixed width so that they sort lexically.
+	trashTimeLayout = "20060102T150405.000000000Z"
+)
+
+var (
+	// ErrNotInTrash is returned by Trash.Restore when the object has no trashed copy.
+	ErrNotInTrash = errors.New("object is not in the trash")
+	// ErrRestoreConflict is returned by Trash.Restore when an object already exists at the original key.
+	ErrRestoreConflict = errors.New("object already exists")
+)
+
+// TrashedObject describes an object moved to the trash.
+type TrashedObject struct {
+	// Key is the key the object was deleted from.
+	Key string
+	// TrashKey is the key of the trashed copy.
+	TrashKey  string
+	DeletedAt time.Time
+	Size      int64
+}
+
+// Trash wraps a Storage so that Delete moves objects to the trash instead of deleting them.
+// A trashed object is saved to "<prefix>/<key>/<deletion time>" of the same storage and kept until the retention passes,
+// so deleting the same key again keeps every deleted copy. Deleting a key under the prefix deletes it permanently.
+// Listing the trash, restoring and sweeping require the storage to implement Lister.
+type Trash struct {
+	storage Storage
+
+	prefix    string
+	retention time.Duration
+	now       func() time.Time
+}
+
+type TrashOptionFunc func(t *Trash)
+
+// TrashOptionWithPrefix sets the prefix of the trashed objects. It defaults to ".trash".
+func TrashOptionWithPrefix(prefix string) TrashOptionFunc {
+	return func(t *Trash) {
+		t.prefix = path.Clean(prefix)
+	}
+}
+
+// TrashOptionWithRetention sets how long trashed objects are kept. It defaults to 30 days.
+func TrashOptionWithRetention(retention time.Duration) TrashOptionFunc {
+	return func(t *Trash) {
+		t.retention = retention
+	}
+}
+
+// TrashOptionWithClock sets the function returning the current time, which stamps deletions and judges the retention.
+// It defaults to time.Now.
+func TrashOptionWithClock(now func() time.Time) TrashOptionFunc {
+	return func(t *Trash) {
+		t.now = now
+	}
+}
+
+func NewTrash(s Storage, opts ...TrashOptionFunc) *Trash {
+	t := &Trash{
+		storage:   s,
+		prefix:    defaultTrashPrefix,
+		retention: defaultTrashRetention,
+		now:       time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+func (t *Trash) Save(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error) {
+	return t.storage.Save(ctx, filePath, data, opts...)
+}
+
+func (t *Trash) Get(ctx context.Context, filePath string) ([]byte, error) {
+	return t.storage.Get(ctx, filePath)
+}
+
+// Delete moves the object to the trash keeping its content type and content disposition when the storage implements Stater.
+// Objects under the trash prefix are deleted permanently.
+func (t *Trash) Delete(ctx context.Context, filePath string) error {
+	if t.inTrash(filePath) {
+		return t.storage.Delete(ctx, filePath)
+	}
+
+	data, err := t.storage.Get(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	// nothing to keep, so the storage decides how to delete a missing object
+	if data == nil {
+		return t.storage.Delete(ctx, filePath)
+	}
+
+	opts, err := copyOptions(ctx, t.storage, filePath)
+	if err != nil {
+		return err
+	}
+
+	trashKey := t.trashKey(filePath, t.now())
+	if _, err := t.storage.Save(ctx, trashKey, data, opts...); err != nil {
+		return fmt.Errorf("failed to move %s to the trash. %w", filePath, err)
+	}
+
+	return t.storage.Delete(ctx, filePath)
+}
+
+func (t *Trash) Ping(ctx context.Context) error {
+	return t.storage.Ping(ctx)
+}
+
+func (t *Trash) GetRange(ctx context.Context, filePath string, offset, length int64) ([]byte, error) {
+	rg, ok := t.storage.(RangeGetter)
+	if !ok {
+		return nil, fmt.Errorf("%T does not support range reads: %w", t.storage, ErrNotSupported)
+	}
+
+	return rg.GetRange(ctx, filePath, offset, length)
+}
+
+func (t *Trash) Stat(ctx context.Context, filePath string) (*object.Info, error) {
+	return stat(ctx, t.storage, filePath)
+}
+
+// List lists objects except the trashed ones.
+func (t *Trash) List(ctx context.Context, prefix string) ([]object.Info, error) {
+	infos, err := list(ctx, t.storage, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]object.Info, 0, len(infos))
+	for _, info := range infos {
+		if t.inTrash(info.Key) {
+			continue
+		}
+
+		filtered = append(filtered, info)
+	}
+
+	return filtered, nil
+}
+
+// ListTrash returns the trashed objects whose original key starts with prefix, sorted by the key and the deletion time.
+func (t *Trash) ListTrash(ctx context.Context, prefix string) ([]TrashedObject, error) {
+	infos, err := list(ctx, t.storage, t.prefix+"/"+prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	objs := make([]TrashedObject, 0, len(infos))
+	for _, info := range infos {
+		obj, ok := t.parseTrashKey(info.Key)
+		if !ok {
+			continue
+		}
+
+		obj.Size = info.Size
+		objs = append(objs, obj)
+	}
+
+	sort.Slice(objs, func(i, j int) bool {
+		if objs[i].Key != objs[j].Key {
+			return objs[i].Key < objs[j].Key
+		}
+
+		return objs[i].DeletedAt.Before(objs[j].DeletedAt)
+	})
+
+	return objs, nil
+}
+
+// Restore moves the most recently deleted copy of filePath back from the trash and returns it.
+// Older copies stay in the trash. It returns ErrNotInTrash when there is no copy
+// and ErrRestoreConflict when an object exists at filePath.
+func (t *Trash) Restore(ctx context.Context, filePath string) (*TrashedObject, error) {
+	objs, err := t.ListTrash(ctx, filePath+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *TrashedObject
+	for i := range objs {
+		if objs[i].Key == filePath {
+			latest = &objs[i]
+		}
+	}
+
+	if latest == nil {
+		return nil, fmt.Errorf("%s: %w", filePath, ErrNotInTrash)
+	}
+
+	current, err := t.storage.Get(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, ErrRestoreConflict)
+	}
+
+	data, err := t.storage.Get(ctx, latest.TrashKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// the copy was purged after listing
+	if data == nil {
+		return nil, fmt.Errorf("%s: %w", filePath, ErrNotInTrash)
+	}
+
+	opts, err := copyOptions(ctx, t.storage, latest.TrashKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := t.storage.Save(ctx, filePath, data, opts...); err != nil {
+		return nil, fmt.Errorf("failed to restore %s. %w", filePath, err)
+	}
+
+	if err := t.storage.Delete(ctx, latest.TrashKey); err != nil {
+		return nil, err
+	}
+
+	return latest, nil
+}
+
+// StartJanitor starts a goroutine that purges trashed objects past the retention every interval until ctx is canceled.
+func (t *Trash) StartJanitor(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := t.SweepExpired(ctx); err != nil {
+					log.FromContext(ctx).Error(err, "failed to sweep the trash")
+				}
+			}
+		}
+	}()
+}
+
+// SweepExpired permanently deletes trashed objects past the retention and returns their trash keys.
+func (t *Trash) SweepExpired(ctx context.Context) ([]string, error) {
+	objs, err := t.ListTrash(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	logger := log.FromContext(ctx)
+	expiredBefore := t.now().Add(-t.retention)
+
+	var deleted []string
+	var errs []error
+	for _, obj := range objs {
+		if !obj.DeletedAt.Before(expiredBefore) {
+			continue
+		}
+
+		if err := t.storage.Delete(ctx, obj.TrashKey); err != nil {
+			errs = append(errs, fmt.Errorf("failed to purge %s. %w", obj.TrashKey, err))
+			continue
+		}
+
+		logger.Info("purged trashed object", "key", obj.Key, "trashKey", obj.TrashKey, "deletedAt", obj.DeletedAt)
+		deleted = append(deleted, obj.TrashKey)
+	}
+
+	return deleted, joinErrors(errs...)
+}
+
+func (t *Trash) inTrash(filePath string) bool {
+	return strings.HasPrefix(filePath, t.prefix+"/")
+}
+
+func (t *Trash) trashKey(filePath string, deletedAt time.Time) string {
+	return t.prefix + "/" + filePath + "/" + deletedAt.UTC().Format(trashTimeLayout)
+}
+
+func (t *Trash) parseTrashKey(trashKey string) (TrashedObject, bool) {
+	rest := strings.TrimPrefix(trashKey, t.prefix+"/")
+
+	i := strings.LastIndex(rest, "/")
+	if i <= 0 {
+		return TrashedObject{}, false
+	}
+
+	deletedAt, err := time.Parse(trashTimeLayout, rest[i+1:])
+	if err != nil {
+		return TrashedObject{}, false
+	}
+
+	return TrashedObject{
+		Key:       rest[:i],
+		TrashKey:  trashKey,
+		DeletedAt: deletedAt,
+	}, true
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hatappi/go-kit/storage/option"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+
+	current := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	memoryProvider := provider.NewMemory()
+	s := NewTrash(memoryProvider,
+		TrashOptionWithRetention(24*time.Hour),
+		TrashOptionWithClock(func() time.Time { return current }),
+	)
+
+	if _, err := s.Save(ctx, "a/test.csv", []byte("v1"), option.SaveOptionWithContentType("text/csv")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Save(ctx, "b.txt", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Delete(ctx, "a/test.csv"); err != nil {
+		t.Fatal(err)
+	}
+
+	current = current.Add(time.Hour)
+	if _, err := s.Save(ctx, "a/test.csv", []byte("v2"), option.SaveOptionWithContentType("text/csv")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a/test.csv"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Delete(ctx, "missing.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.Get(ctx, "a/test.csv")
+	if err != nil || data != nil {
+		t.Fatalf("deleted object should not exist. data: %s, err: %v", data, err)
+	}
+
+	infos, err := s.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Key != "b.txt" {
+		t.Errorf("trashed objects should be hidden. %+v", infos)
+	}
+
+	trashed, err := s.ListTrash(ctx, "a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TrashedObject{
+		{Key: "a/test.csv", TrashKey: ".trash/a/test.csv/20220101T000000.000000000Z", DeletedAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), Size: 2},
+		{Key: "a/test.csv", TrashKey: ".trash/a/test.csv/20220101T010000.000000000Z", DeletedAt: time.Date(2022, 1, 1, 1, 0, 0, 0, time.UTC), Size: 2},
+	}
+	if d := cmp.Diff(want, trashed); d != "" {
+		t.Fatalf("trashed objects were a mismatch. %s", d)
+	}
+
+	restored, err := s.Restore(ctx, "a/test.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(&want[1], restored); d != "" {
+		t.Errorf("the latest copy should be restored. %s", d)
+	}
+
+	data, err = s.Get(ctx, "a/test.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "v2" {
+		t.Errorf("unexpected contents. %s", data)
+	}
+
+	info, err := s.Stat(ctx, "a/test.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ContentType != "text/csv" {
+		t.Errorf("content type should be kept. %s", info.ContentType)
+	}
+
+	if _, err := s.Restore(ctx, "a/test.csv"); !errors.Is(err, ErrRestoreConflict) {
+		t.Errorf("Restore should fail with ErrRestoreConflict. %v", err)
+	}
+
+	if _, err := s.Restore(ctx, "b.txt"); !errors.Is(err, ErrNotInTrash) {
+		t.Errorf("Restore should fail with ErrNotInTrash. %v", err)
+	}
+
+	current = current.Add(24 * time.Hour)
+
+	deleted, err := s.SweepExpired(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff([]string{want[0].TrashKey}, deleted); d != "" {
+		t.Errorf("deleted keys were a mismatch. %s", d)
+	}
+
+	trashed, err = s.ListTrash(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 0 {
+		t.Errorf("trash should be empty. %+v", trashed)
+	}
+}
+
+func TestTrashDeleteInTrash(t *testing.T) {
+	ctx := context.Background()
+
+	memoryProvider := provider.NewMemory()
+	s := NewTrash(memoryProvider, TrashOptionWithPrefix("trash/"))
+
+	if _, err := s.Save(ctx, "test.txt", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "test.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	trashed, err := s.ListTrash(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 1 {
+		t.Fatalf("unexpected trashed objects. %+v", trashed)
+	}
+
+	if err := s.Delete(ctx, trashed[0].TrashKey); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := memoryProvider.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 0 {
+		t.Errorf("trashed object should be deleted permanently. %+v", infos)
+	}
+}
@@ -0,0 +1,91 @@
+package tus
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage"
+)
+
+// StartJanitor starts a goroutine that deletes abandoned uploads every interval until ctx is canceled.
+// The storage must implement storage.Lister.
+func (h *Handler) StartJanitor(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := h.SweepAbandoned(ctx); err != nil {
+					log.FromContext(ctx).Error(err, "failed to sweep abandoned uploads")
+				}
+			}
+		}
+	}()
+}
+
+// SweepAbandoned deletes incomplete uploads which have not been patched within the expiration and returns their IDs.
+// The state of complete uploads is deleted as well once it expires, leaving the assembled objects.
+func (h *Handler) SweepAbandoned(ctx context.Context) ([]string, error) {
+	l, ok := h.storage.(storage.Lister)
+	if !ok {
+		return nil, storage.ErrNotSupported
+	}
+
+	infos, err := l.List(ctx, h.uploadPrefix+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	logger := log.FromContext(ctx)
+
+	var deleted []string
+	for _, info := range infos {
+		if path.Base(info.Key) != infoFileName {
+			continue
+		}
+
+		id := strings.TrimPrefix(path.Dir(info.Key), h.uploadPrefix+"/")
+		if !validID(id) {
+			continue
+		}
+
+		swept, err := h.sweep(ctx, id)
+		if err != nil {
+			return deleted, err
+		}
+
+		if swept {
+			deleted = append(deleted, id)
+			logger.Info("deleted abandoned upload", "id", id)
+		}
+	}
+
+	return deleted, nil
+}
+
+func (h *Handler) sweep(ctx context.Context, id string) (bool, error) {
+	unlock := h.lock(id)
+	defer unlock()
+
+	u, err := h.readUpload(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	if u == nil || !h.expired(u) {
+		return false, nil
+	}
+
+	if err := h.deleteUpload(ctx, u); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
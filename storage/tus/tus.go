@@ -0,0 +1,572 @@
+// Package tus implements a resumable upload server of the tus protocol 1.0.0 on top of storage.Storage.
+//
+// Supported extensions are creation, termination and expiration. See https://tus.io/protocols/resumable-upload.
+// Each PATCH request is saved as a chunk object and the chunks are assembled into the final object
+// by storage.Compose once the upload is complete.
+package tus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hatappi/go-kit/log"
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+const (
+	// Version is the version of the tus protocol implemented by Handler.
+	Version = "1.0.0"
+
+	defaultUploadPrefix = ".tus"
+	defaultMaxChunkSize = 32 << 20
+	defaultExpiration   = 24 * time.Hour
+
+	infoFileName = "info.json"
+)
+
+var errUploadNotFound = errors.New("upload not found")
+
+// Upload is the state of an upload.
+type Upload struct {
+	ID       string            `json:"id"`
+	Length   int64             `json:"length"`
+	Offset   int64             `json:"offset"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Chunks   []string          `json:"chunks,omitempty"`
+
+	// Key is the key of the assembled object, set once the upload is complete.
+	Key string `json:"key,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Complete reports whether all bytes have been received.
+func (u *Upload) Complete() bool {
+	return u.Offset == u.Length
+}
+
+// Handler serves the tus protocol. Use http.StripPrefix to mount it under basePath.
+type Handler struct {
+	storage  storage.Storage
+	basePath string
+
+	uploadPrefix string
+	maxSize      int64
+	maxChunkSize int64
+	expiration   time.Duration
+	keyFunc      func(*Upload) string
+	onComplete   func(context.Context, *Upload)
+
+	locksMu sync.Mutex
+	locks   map[string]*uploadLock
+}
+
+type uploadLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+type Option func(*Handler)
+
+// WithUploadPrefix sets the prefix under which the chunks and states of uploads are saved. The default is .tus.
+func WithUploadPrefix(prefix string) Option {
+	return func(h *Handler) {
+		h.uploadPrefix = prefix
+	}
+}
+
+// WithMaxSize rejects uploads larger than size bytes.
+func WithMaxSize(size int64) Option {
+	return func(h *Handler) {
+		h.maxSize = size
+	}
+}
+
+// WithMaxChunkSize sets the maximum bytes accepted by a PATCH request, since a chunk is held in memory. The default is 32MiB.
+func WithMaxChunkSize(size int64) Option {
+	return func(h *Handler) {
+		h.maxChunkSize = size
+	}
+}
+
+// WithExpiration sets the duration after the last PATCH request until an incomplete upload is abandoned. The default is 24 hours.
+func WithExpiration(d time.Duration) Option {
+	return func(h *Handler) {
+		h.expiration = d
+	}
+}
+
+// WithKeyFunc sets the function deciding the key of the assembled object. The default is the upload ID.
+func WithKeyFunc(fn func(*Upload) string) Option {
+	return func(h *Handler) {
+		h.keyFunc = fn
+	}
+}
+
+// WithOnComplete sets the function called after an upload is assembled.
+func WithOnComplete(fn func(context.Context, *Upload)) Option {
+	return func(h *Handler) {
+		h.onComplete = fn
+	}
+}
+
+// New returns a Handler. basePath is the path the handler is mounted on and is used for the Location header.
+func New(s storage.Storage, basePath string, opts ...Option) *Handler {
+	h := &Handler{
+		storage:      s,
+		basePath:     basePath,
+		uploadPrefix: defaultUploadPrefix,
+		maxChunkSize: defaultMaxChunkSize,
+		expiration:   defaultExpiration,
+		keyFunc: func(u *Upload) string {
+			return u.ID
+		},
+		locks: map[string]*uploadLock{},
+	}
+
+	for _, o := range opts {
+		o(h)
+	}
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", Version)
+
+	if r.Method == http.MethodOptions {
+		h.options(w)
+		return
+	}
+
+	if r.Header.Get("Tus-Resumable") != Version {
+		w.Header().Set("Tus-Version", Version)
+		http.Error(w, "unsupported tus version", http.StatusPreconditionFailed)
+		return
+	}
+
+	id := strings.Trim(r.URL.Path, "/")
+
+	if id == "" {
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		h.create(w, r)
+		return
+	}
+
+	// the ID becomes a part of the keys, so anything newID does not generate is rejected
+	if !validID(id) {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodHead:
+		h.head(w, r, id)
+	case http.MethodPatch:
+		h.patch(w, r, id)
+	case http.MethodDelete:
+		h.terminate(w, r, id)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) options(w http.ResponseWriter) {
+	w.Header().Set("Tus-Version", Version)
+	w.Header().Set("Tus-Extension", "creation,termination,expiration")
+	if h.maxSize > 0 {
+		w.Header().Set("Tus-Max-Size", strconv.FormatInt(h.maxSize, 10))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+
+	if h.maxSize > 0 && length > h.maxSize {
+		http.Error(w, "upload is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	metadata, err := parseMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		http.Error(w, "invalid Upload-Metadata", http.StatusBadRequest)
+		return
+	}
+
+	id, err := newID()
+	if err != nil {
+		h.serverError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	u := &Upload{
+		ID:        id,
+		Length:    length,
+		Metadata:  metadata,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if u.Complete() {
+		if err := h.complete(ctx, u); err != nil {
+			h.serverError(w, r, err)
+			return
+		}
+	}
+
+	if err := h.saveUpload(ctx, u); err != nil {
+		h.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", path.Join(h.basePath, id))
+	h.setExpires(w, u)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) head(w http.ResponseWriter, r *http.Request, id string) {
+	u, err := h.getUpload(r.Context(), id)
+	if err != nil {
+		h.uploadError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(u.Length, 10))
+	if len(u.Metadata) > 0 {
+		w.Header().Set("Upload-Metadata", formatMetadata(u.Metadata))
+	}
+	h.setExpires(w, u)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) patch(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	unlock := h.lock(id)
+	defer unlock()
+
+	u, err := h.getUpload(ctx, id)
+	if err != nil {
+		h.uploadError(w, r, err)
+		return
+	}
+
+	if offset != u.Offset {
+		http.Error(w, "Upload-Offset does not match", http.StatusConflict)
+		return
+	}
+
+	if u.Complete() {
+		http.Error(w, "upload is already complete", http.StatusForbidden)
+		return
+	}
+
+	limit := u.Length - u.Offset
+	if limit > h.maxChunkSize {
+		limit = h.maxChunkSize
+	}
+
+	// keep the bytes received before the connection is lost, so that the client can resume from them
+	data, readErr := io.ReadAll(io.LimitReader(r.Body, limit))
+	if len(data) > 0 {
+		chunk := h.uploadKey(id, fmt.Sprintf("%020d", u.Offset))
+		if _, err := h.storage.Save(ctx, chunk, data); err != nil {
+			h.serverError(w, r, err)
+			return
+		}
+
+		u.Chunks = append(u.Chunks, chunk)
+		u.Offset += int64(len(data))
+		u.UpdatedAt = time.Now()
+
+		if u.Complete() {
+			if err := h.complete(ctx, u); err != nil {
+				h.serverError(w, r, err)
+				return
+			}
+		}
+
+		if err := h.saveUpload(ctx, u); err != nil {
+			h.serverError(w, r, err)
+			return
+		}
+	}
+
+	if readErr != nil {
+		log.FromContext(ctx).Error(readErr, "failed to read upload chunk", "id", id, "offset", u.Offset)
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	h.setExpires(w, u)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) terminate(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+
+	unlock := h.lock(id)
+	defer unlock()
+
+	u, err := h.getUpload(ctx, id)
+	if err != nil {
+		h.uploadError(w, r, err)
+		return
+	}
+
+	if err := h.deleteUpload(ctx, u); err != nil {
+		h.serverError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// complete assembles the chunks into the final object and deletes the chunks.
+func (h *Handler) complete(ctx context.Context, u *Upload) error {
+	key := h.keyFunc(u)
+
+	if _, err := storage.Compose(ctx, h.storage, key, u.Chunks, saveOptions(u.Metadata)...); err != nil {
+		return err
+	}
+
+	for _, chunk := range u.Chunks {
+		if err := h.storage.Delete(ctx, chunk); err != nil {
+			return err
+		}
+	}
+
+	u.Key = key
+	u.Chunks = nil
+
+	if h.onComplete != nil {
+		h.onComplete(ctx, u)
+	}
+
+	return nil
+}
+
+// saveOptions maps the "filetype" and "filename" metadata sent by tus clients such as Uppy
+// to the content type and the content disposition of the object. Invalid values are ignored.
+func saveOptions(metadata map[string]string) []option.SaveOptionFunc {
+	var opts []option.SaveOptionFunc
+
+	if ft := metadata["filetype"]; ft != "" {
+		if _, _, err := mime.ParseMediaType(ft); err == nil {
+			opts = append(opts, option.SaveOptionWithContentType(ft))
+		}
+	}
+
+	if fn := metadata["filename"]; fn != "" {
+		if cd := mime.FormatMediaType("attachment", map[string]string{"filename": fn}); cd != "" {
+			opts = append(opts, option.SaveOptionWithContentDisposition(cd))
+		}
+	}
+
+	return opts
+}
+
+// getUpload returns the upload, or errUploadNotFound when it does not exist or is abandoned.
+func (h *Handler) getUpload(ctx context.Context, id string) (*Upload, error) {
+	u, err := h.readUpload(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if u == nil || (!u.Complete() && h.expired(u)) {
+		return nil, errUploadNotFound
+	}
+
+	return u, nil
+}
+
+// readUpload returns the saved state of the upload. It returns nil when it does not exist.
+func (h *Handler) readUpload(ctx context.Context, id string) (*Upload, error) {
+	raw, err := h.storage.Get(ctx, h.uploadKey(id, infoFileName))
+	if err != nil || raw == nil {
+		return nil, err
+	}
+
+	var u Upload
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (h *Handler) saveUpload(ctx context.Context, u *Upload) error {
+	raw, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+
+	_, err = h.storage.Save(ctx, h.uploadKey(u.ID, infoFileName), raw)
+
+	return err
+}
+
+func (h *Handler) deleteUpload(ctx context.Context, u *Upload) error {
+	for _, chunk := range u.Chunks {
+		if err := h.storage.Delete(ctx, chunk); err != nil {
+			return err
+		}
+	}
+
+	return h.storage.Delete(ctx, h.uploadKey(u.ID, infoFileName))
+}
+
+func (h *Handler) uploadKey(id, name string) string {
+	return path.Join(h.uploadPrefix, id, name)
+}
+
+func (h *Handler) expired(u *Upload) bool {
+	return time.Since(u.UpdatedAt) > h.expiration
+}
+
+func (h *Handler) setExpires(w http.ResponseWriter, u *Upload) {
+	if u.Complete() {
+		return
+	}
+
+	w.Header().Set("Upload-Expires", u.UpdatedAt.Add(h.expiration).UTC().Format(http.TimeFormat))
+}
+
+// lock serializes requests to the same upload within this process.
+func (h *Handler) lock(id string) func() {
+	h.locksMu.Lock()
+	l, ok := h.locks[id]
+	if !ok {
+		l = &uploadLock{}
+		h.locks[id] = l
+	}
+	l.refs++
+	h.locksMu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		h.locksMu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(h.locks, id)
+		}
+		h.locksMu.Unlock()
+	}
+}
+
+func (h *Handler) uploadError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errUploadNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.serverError(w, r, err)
+}
+
+func (h *Handler) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	log.FromContext(r.Context()).Error(err, "failed to handle upload", "method", r.Method, "path", r.URL.Path)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// validID reports whether id has the form generated by newID, 32 lowercase hex characters.
+func validID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+
+	for _, c := range id {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseMetadata parses Upload-Metadata, comma separated pairs of a key and a base64 encoded value.
+func parseMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, " ", 2)
+		if len(kv) == 1 {
+			metadata[kv[0]] = ""
+			continue
+		}
+
+		v, err := base64.StdEncoding.DecodeString(kv[1])
+		if err != nil {
+			return nil, err
+		}
+
+		metadata[kv[0]] = string(v)
+	}
+
+	return metadata, nil
+}
+
+func formatMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for k, v := range metadata {
+		pairs = append(pairs, k+" "+base64.StdEncoding.EncodeToString([]byte(v)))
+	}
+
+	return strings.Join(pairs, ",")
+}
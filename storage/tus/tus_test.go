@@ -0,0 +1,236 @@
+package tus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hatappi/go-kit/storage"
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestHandler(t *testing.T) {
+	testCases := []struct {
+		name    string
+		storage func(t *testing.T) storage.Storage
+	}{
+		{
+			name: "disk",
+			storage: func(t *testing.T) storage.Storage {
+				return provider.NewDisk(t.TempDir())
+			},
+		},
+		{
+			name: "memory",
+			storage: func(t *testing.T) storage.Storage {
+				return provider.NewMemory()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := tc.storage(t)
+
+			var completed *Upload
+			h := New(s, "/files", WithOnComplete(func(ctx context.Context, u *Upload) {
+				completed = u
+			}), WithKeyFunc(func(u *Upload) string {
+				return path.Join("uploads", u.Metadata["filename"])
+			}))
+
+			res := do(h, http.MethodPost, "/", "", map[string]string{
+				"Upload-Length":   "10",
+				"Upload-Metadata": "filename dGVzdC50eHQ=,filetype dGV4dC9wbGFpbg==",
+			})
+			if res.Code != http.StatusCreated {
+				t.Fatalf("unexpected status. %d %s", res.Code, res.Body)
+			}
+
+			location := res.Header().Get("Location")
+			if !strings.HasPrefix(location, "/files/") {
+				t.Fatalf("unexpected location. %s", location)
+			}
+			target := strings.TrimPrefix(location, "/files")
+
+			res = do(h, http.MethodPatch, target, "01234", map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "0",
+			})
+			if res.Code != http.StatusNoContent || res.Header().Get("Upload-Offset") != "5" {
+				t.Fatalf("unexpected response. %d %s", res.Code, res.Header().Get("Upload-Offset"))
+			}
+
+			res = do(h, http.MethodPatch, target, "xxxxx", map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "0",
+			})
+			if res.Code != http.StatusConflict {
+				t.Fatalf("mismatched offset should conflict. %d", res.Code)
+			}
+
+			res = do(h, http.MethodHead, target, "", nil)
+			if res.Code != http.StatusOK || res.Header().Get("Upload-Offset") != "5" || res.Header().Get("Upload-Length") != "10" {
+				t.Fatalf("unexpected response. %d %v", res.Code, res.Header())
+			}
+
+			res = do(h, http.MethodPatch, target, "56789", map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "5",
+			})
+			if res.Code != http.StatusNoContent || res.Header().Get("Upload-Offset") != "10" {
+				t.Fatalf("unexpected response. %d %s", res.Code, res.Header().Get("Upload-Offset"))
+			}
+
+			if completed == nil || completed.Key != "uploads/test.txt" {
+				t.Fatalf("upload was not completed. %+v", completed)
+			}
+
+			data, err := s.Get(ctx, "uploads/test.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != "0123456789" {
+				t.Fatalf("unexpected contents. %s", data)
+			}
+
+			info, err := s.(storage.Stater).Stat(ctx, "uploads/test.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.ContentType != "text/plain" || info.ContentDisposition != `attachment; filename=test.txt` {
+				t.Fatalf("metadata should be kept. %+v", info)
+			}
+
+			res = do(h, http.MethodDelete, target, "", nil)
+			if res.Code != http.StatusNoContent {
+				t.Fatalf("unexpected status. %d", res.Code)
+			}
+
+			res = do(h, http.MethodHead, target, "", nil)
+			if res.Code != http.StatusNotFound {
+				t.Fatalf("terminated upload should not be found. %d", res.Code)
+			}
+		})
+	}
+}
+
+func TestHandlerRequiresTusResumable(t *testing.T) {
+	h := New(provider.NewMemory(), "/files")
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Upload-Length", "1")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("unexpected status. %d", rec.Code)
+	}
+
+	res := do(h, http.MethodOptions, "/", "", nil)
+	if res.Code != http.StatusNoContent || res.Header().Get("Tus-Version") != Version {
+		t.Fatalf("unexpected response. %d %v", res.Code, res.Header())
+	}
+}
+
+func TestHandlerRejectsInvalidID(t *testing.T) {
+	ctx := context.Background()
+
+	s := provider.NewMemory()
+	if _, err := s.Save(ctx, "info.json", []byte(`{"id":"..","length":10}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	h := New(s, "/files")
+
+	for _, target := range []string{
+		"/..",
+		"/.",
+		"/%2e%2e",
+		"/0123456789ABCDEF0123456789ABCDEF",
+		"/0123456789abcdef0123456789abcdeg",
+		"/0123456789abcdef",
+		"/0123456789abcdef0123456789abcdef0",
+	} {
+		for _, method := range []string{http.MethodHead, http.MethodPatch, http.MethodDelete} {
+			res := do(h, method, target, "0123456789", map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": "0",
+			})
+			if res.Code != http.StatusNotFound {
+				t.Errorf("%s %s: unexpected status. %d", method, target, res.Code)
+			}
+		}
+	}
+
+	data, err := s.Get(ctx, "info.json")
+	if err != nil || data == nil {
+		t.Errorf("object outside of the upload prefix should be kept. data: %s, err: %v", data, err)
+	}
+
+	infos, err := s.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 {
+		t.Errorf("no object should be written. %+v", infos)
+	}
+}
+
+func TestSweepAbandoned(t *testing.T) {
+	ctx := context.Background()
+	s := provider.NewMemory()
+
+	h := New(s, "/files", WithExpiration(time.Millisecond))
+
+	res := do(h, http.MethodPost, "/", "", map[string]string{"Upload-Length": "10"})
+	target := strings.TrimPrefix(res.Header().Get("Location"), "/files")
+
+	do(h, http.MethodPatch, target, "01234", map[string]string{
+		"Content-Type":  "application/offset+octet-stream",
+		"Upload-Offset": "0",
+	})
+
+	time.Sleep(10 * time.Millisecond)
+
+	deleted, err := h.SweepAbandoned(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 1 || "/"+deleted[0] != target {
+		t.Fatalf("unexpected deleted uploads. %v", deleted)
+	}
+
+	infos, err := s.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 0 {
+		t.Fatalf("chunks were not deleted. %+v", infos)
+	}
+}
+
+func do(h http.Handler, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", Version)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec
+}
@@ -3,6 +3,8 @@ package storage
 type StorageType string
 
 const (
-	StorageTypeDisk StorageType = "disk"
-	StorageTypeS3   StorageType = "s3"
+	StorageTypeDisk   StorageType = "disk"
+	StorageTypeS3     StorageType = "s3"
+	StorageTypeSFTP   StorageType = "sftp"
+	StorageTypeWebDAV StorageType = "webdav"
 )
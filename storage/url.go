@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+// defaultS3Region is used when the region is not set in a s3 URL, same as the default of Config.
+const defaultS3Region = "ap-northeast-1"
+
+// URLOpener builds a Storage from a URL.
+type URLOpener func(ctx context.Context, u *url.URL) (Storage, error)
+
+var (
+	urlOpenersMu sync.RWMutex
+	urlOpeners   = map[string]URLOpener{}
+)
+
+func init() {
+	RegisterURLOpener("file", openDiskURL)
+	RegisterURLOpener("s3", openS3URL)
+	RegisterURLOpener("mem", openMemoryURL)
+}
+
+// RegisterURLOpener makes a Storage available by Open for URLs with the scheme.
+// It panics when the scheme is already registered.
+func RegisterURLOpener(scheme string, opener URLOpener) {
+	urlOpenersMu.Lock()
+	defer urlOpenersMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	if _, ok := urlOpeners[scheme]; ok {
+		panic(fmt.Sprintf("storage: URL opener for %s is already registered", scheme))
+	}
+
+	urlOpeners[scheme] = opener
+}
+
+// Open builds a Storage from a URL such as
+//
+//	file:///var/data
+//	s3://bucket/prefix?region=ap-northeast-1
+//	mem://
+func Open(ctx context.Context, rawURL string) (Storage, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	urlOpenersMu.RLock()
+	opener, ok := urlOpeners[strings.ToLower(u.Scheme)]
+	urlOpenersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported storage scheme: %q, registered: %s", u.Scheme, strings.Join(urlSchemes(), ", "))
+	}
+
+	return opener(ctx, u)
+}
+
+func urlSchemes() []string {
+	urlOpenersMu.RLock()
+	defer urlOpenersMu.RUnlock()
+
+	schemes := make([]string, 0, len(urlOpeners))
+	for scheme := range urlOpeners {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+
+	return schemes
+}
+
+func openDiskURL(ctx context.Context, u *url.URL) (Storage, error) {
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, fmt.Errorf("file URL must not have a host: %s", u.Host)
+	}
+
+	if u.Path == "" {
+		return nil, fmt.Errorf("file URL must have a path: %s", u)
+	}
+
+	return provider.NewDisk(u.Path), nil
+}
+
+func openS3URL(ctx context.Context, u *url.URL) (Storage, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("s3 URL must have a bucket name: %s", u)
+	}
+
+	region := u.Query().Get("region")
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	return provider.NewS3(u.Host, strings.Trim(u.Path, "/"), region)
+}
+
+func openMemoryURL(ctx context.Context, u *url.URL) (Storage, error) {
+	return provider.NewMemory(), nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/hatappi/go-kit/storage/provider"
+)
+
+func TestOpen(t *testing.T) {
+	testCases := []struct {
+		name string
+		url  string
+
+		wantType string
+		wantErr  bool
+	}{
+		{
+			name:     "file",
+			url:      "file:///tmp/data",
+			wantType: "*provider.Disk",
+		},
+		{
+			name:     "s3",
+			url:      "s3://bucket/prefix?region=us-east-1",
+			wantType: "*provider.S3",
+		},
+		{
+			name:     "memory",
+			url:      "mem://",
+			wantType: "*provider.Memory",
+		},
+		{
+			name:     "registered scheme",
+			url:      "test-open://",
+			wantType: "*provider.Memory",
+		},
+		{
+			name:    "s3 without bucket",
+			url:     "s3:///prefix",
+			wantErr: true,
+		},
+		{
+			name:    "file with host",
+			url:     "file://example.com/data",
+			wantErr: true,
+		},
+		{
+			name:    "unknown scheme",
+			url:     "unknown://",
+			wantErr: true,
+		},
+	}
+
+	RegisterURLOpener("test-open", func(ctx context.Context, u *url.URL) (Storage, error) {
+		return provider.NewMemory(), nil
+	})
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Open(context.Background(), tc.url)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err: %v", err)
+			}
+
+			if tc.wantErr {
+				return
+			}
+
+			if actual := fmt.Sprintf("%T", s); actual != tc.wantType {
+				t.Fatalf("unexpected storage. expected: %s, actual: %s", tc.wantType, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/hatappi/go-kit/storage/object"
+	"github.com/hatappi/go-kit/storage/option"
+)
+
+// Versioner is implemented by storages that keep multiple versions of an object.
+type Versioner interface {
+	SaveVersion(ctx context.Context, filePath string, data []byte, opts ...option.SaveOptionFunc) (string, error)
+	GetVersion(ctx context.Context, filePath string, versionID string) ([]byte, error)
+	DeleteVersion(ctx context.Context, filePath string, versionID string) error
+	ListVersions(ctx context.Context, filePath string) ([]object.Version, error)
+}
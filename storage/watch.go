@@ -0,0 +1,13 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/hatappi/go-kit/storage/object"
+)
+
+// Watcher is implemented by storages that can notify changes of objects.
+// The returned channel is closed when ctx is canceled.
+type Watcher interface {
+	Watch(ctx context.Context, prefix string) (<-chan object.Event, error)
+}